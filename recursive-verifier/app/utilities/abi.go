@@ -0,0 +1,45 @@
+package utilities
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// abiWordSize is the size of every head and tail slot of the Solidity ABI encoding.
+const abiWordSize = 32
+
+// ProofToABI returns abi.encode(uint256[8] proof, uint256[2][] commitments, uint256[2] commitmentPok) for a
+// BN254 proof, the words being laid out as WriteProofInSolidity writes them. Prefix it with the 4 byte
+// selector of the verifier function to get the calldata of a call taking these three arguments. The
+// encoding is hand-rolled as it only involves static words and one dynamic array:
+//
+//	proof (8 words) || offset of commitments (1 word, always 352) || commitmentPok (2 words)
+//	number of commitments (1 word) || commitments (2 words each)
+func ProofToABI(proof groth16.Proof) ([]byte, error) {
+	proofInSol, commitmentsInSol, commitmentPokInSol, err := proofToSolidityWords(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	headWords := ProofWordCount + 1 + CommitmentPokWordCount
+	nbCommitments := len(commitmentsInSol) / WordsPerCommitment
+	out := make([]byte, 0, (headWords+1+len(commitmentsInSol))*abiWordSize)
+	appendWord := func(v *big.Int) {
+		var w [abiWordSize]byte
+		out = append(out, v.FillBytes(w[:])...)
+	}
+
+	for _, v := range proofInSol {
+		appendWord(v)
+	}
+	appendWord(big.NewInt(int64(headWords * abiWordSize)))
+	for _, v := range commitmentPokInSol {
+		appendWord(v)
+	}
+	appendWord(big.NewInt(int64(nbCommitments)))
+	for _, v := range commitmentsInSol {
+		appendWord(v)
+	}
+	return out, nil
+}
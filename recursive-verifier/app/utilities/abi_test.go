@@ -0,0 +1,85 @@
+package utilities
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// abiVectorProof returns the proof of the pinned ABI vectors: Ar = [1]G1, Bs = G2, Krs = [2]G1 and, with a
+// commitment, Commitments[0] = [3]G1 and CommitmentPok = [4]G1.
+func abiVectorProof(withCommitment bool) *groth16_bn254.Proof {
+	g1, _, g1Aff, g2Aff := bn254.Generators()
+	mul := func(k int64) bn254.G1Affine {
+		var p bn254.G1Jac
+		p.ScalarMultiplication(&g1, big.NewInt(k))
+		var a bn254.G1Affine
+		a.FromJacobian(&p)
+		return a
+	}
+
+	proof := &groth16_bn254.Proof{Ar: g1Aff, Bs: g2Aff, Krs: mul(2)}
+	if withCommitment {
+		proof.Commitments = []bn254.G1Affine{mul(3)}
+		proof.CommitmentPok = mul(4)
+	}
+	return proof
+}
+
+func Test_ProofToABI(t *testing.T) {
+	// abi.encode(uint256[8], uint256[2][], uint256[2]) of abiVectorProof, worked out by hand from the Solidity
+	// ABI specification: 11 head words, the tail offset being 352, then the length and the elements of the
+	// commitments array. G2 coordinates are in EVM order, imaginary parts first.
+	const head = "0000000000000000000000000000000000000000000000000000000000000001" +
+		"0000000000000000000000000000000000000000000000000000000000000002" +
+		"198e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c2" +
+		"1800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed" +
+		"090689d0585ff075ec9e99ad690c3395bc4b313370b38ef355acdadcd122975b" +
+		"12c85ea5db8c6deb4aab71808dcb408fe3d1e7690c43d37b4ce6cc0166fa7daa" +
+		"030644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd3" +
+		"15ed738c0e0a7c92e7845f96b2ae9c0a68a6a449e3538fc7ff3ebf7a5a18a2c4" +
+		"0000000000000000000000000000000000000000000000000000000000000160"
+	for _, tc := range []struct {
+		name  string
+		proof *groth16_bn254.Proof
+		want  string
+	}{
+		{"plain", abiVectorProof(false), head +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000" +
+			"0000000000000000000000000000000000000000000000000000000000000000"},
+		{"committed", abiVectorProof(true), head +
+			"06a7b64af8f414bcbeef455b1da5208c9b592b83ee6599824caa6d2ee9141a76" +
+			"08e74e438cee31ac104ce59b94e45fe98a97d8f8a6e75664ce88ef5a41e72fbc" +
+			"0000000000000000000000000000000000000000000000000000000000000001" +
+			"0769bf9ac56bea3ff40232bcb1b6bd159315d84715b8e679f2d355961915abf0" +
+			"2ab799bee0489429554fdb7c8d086475319e63b40b9c5b57cdf1ff3dd9fe2261"},
+	} {
+		data, err := ProofToABI(tc.proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hex.EncodeToString(data); got != tc.want {
+			t.Fatalf("%s: got\n%s\nexpected\n%s", tc.name, splitWords(got), splitWords(tc.want))
+		}
+	}
+
+	bls, _, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if _, err := ProofToABI(bls); err == nil {
+		t.Fatal("expected a BLS12-381 proof to be rejected")
+	}
+}
+
+// splitWords puts every 32 byte word of an ABI hex encoding on its own line.
+func splitWords(s string) string {
+	var words []string
+	for i := 0; i < len(s); i += 2 * abiWordSize {
+		words = append(words, s[i:min(i+2*abiWordSize, len(s))])
+	}
+	return strings.Join(words, "\n")
+}
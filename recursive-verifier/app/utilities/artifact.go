@@ -0,0 +1,85 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ArtifactKind identifies the type of artifact ReadArtifact decoded.
+type ArtifactKind int
+
+const (
+	ArtifactUnknown ArtifactKind = iota
+	ArtifactProof
+	ArtifactVk
+	ArtifactPk
+	ArtifactCcs
+)
+
+func (k ArtifactKind) String() string {
+	switch k {
+	case ArtifactProof:
+		return "proof"
+	case ArtifactVk:
+		return "vk"
+	case ArtifactPk:
+		return "pk"
+	case ArtifactCcs:
+		return "ccs"
+	}
+	return "unknown"
+}
+
+// ErrUnknownArtifact is returned by ReadArtifact for files whose extension maps to no known artifact kind.
+var ErrUnknownArtifact = errors.New("unknown artifact kind")
+
+// artifactExtensions maps file extensions to the kind of artifact stored under them.
+var artifactExtensions = map[string]ArtifactKind{
+	".proof": ArtifactProof,
+	".vk":    ArtifactVk,
+	".pk":    ArtifactPk,
+	".ccs":   ArtifactCcs,
+}
+
+// ReadArtifact loads a BN254 artifact choosing the reader by the extension of fn: .proof, .vk, .pk or .ccs,
+// with .ccs.gz read gzip compressed. The returned value is a groth16.Proof, groth16.VerifyingKey,
+// groth16.ProvingKey or constraint.ConstraintSystem respectively. Proofs written with a ProofFormatV1 header
+// are recognized by their content, whatever their extension.
+func ReadArtifact(fn string) (any, ArtifactKind, error) {
+	name := strings.TrimSuffix(fn, ".gz")
+	kind := artifactExtensions[filepath.Ext(name)]
+	if name != fn && kind != ArtifactCcs {
+		kind = ArtifactUnknown
+	}
+	// files written with ProofFormatV1 are recognized whatever their name
+	if fileHasProofHeader(fn) {
+		if kind != ArtifactUnknown && kind != ArtifactProof {
+			return nil, kind, fmt.Errorf("%s holds a proof, not a %s", fn, kind)
+		}
+		kind = ArtifactProof
+	}
+
+	var (
+		artifact any
+		err      error
+	)
+	switch kind {
+	case ArtifactProof:
+		artifact, err = ReadProof(fn)
+	case ArtifactVk:
+		artifact, err = ReadVk(fn)
+	case ArtifactPk:
+		artifact, err = ReadPk(fn)
+	case ArtifactCcs:
+		artifact, err = ReadCcsAuto(fn)
+	default:
+		return nil, ArtifactUnknown, fmt.Errorf("%w: %s", ErrUnknownArtifact, fn)
+	}
+	if err != nil {
+		return nil, kind, err
+	}
+
+	return artifact, kind, nil
+}
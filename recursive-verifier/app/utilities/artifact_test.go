@@ -0,0 +1,53 @@
+package utilities
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_ReadArtifact(t *testing.T) {
+	proof, vk, _ := genTestProof(t)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := WriteProof(proof, filepath.Join(dir, "a.proof")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVk(vk, filepath.Join(dir, "a.vk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCcsGzip(ccs, filepath.Join(dir, "a.ccs.gz")); err != nil {
+		t.Fatal(err)
+	}
+
+	artifact, kind, err := ReadArtifact(filepath.Join(dir, "a.proof"))
+	if _, ok := artifact.(groth16.Proof); err != nil || kind != ArtifactProof || !ok {
+		t.Fatalf("unexpected proof artifact %T %v (%v)", artifact, kind, err)
+	}
+	artifact, kind, err = ReadArtifact(filepath.Join(dir, "a.vk"))
+	if _, ok := artifact.(groth16.VerifyingKey); err != nil || kind != ArtifactVk || !ok {
+		t.Fatalf("unexpected vk artifact %T %v (%v)", artifact, kind, err)
+	}
+	artifact, kind, err = ReadArtifact(filepath.Join(dir, "a.ccs.gz"))
+	if _, ok := artifact.(constraint.ConstraintSystem); err != nil || kind != ArtifactCcs || !ok {
+		t.Fatalf("unexpected ccs artifact %T %v (%v)", artifact, kind, err)
+	}
+
+	for _, fn := range []string{"a.json", "a.proof.gz", "proof"} {
+		if _, kind, err := ReadArtifact(filepath.Join(dir, fn)); kind != ArtifactUnknown || !errors.Is(err, ErrUnknownArtifact) {
+			t.Fatalf("expected ErrUnknownArtifact for %s, got %v (%v)", fn, kind, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// A proof batch is a sequence of frames, each holding a big-endian uint32 length followed by
+// that many bytes of a proof serialized with WriteTo.
+const frameHeaderLen = 4
+
+// MaxFrameSize is the largest frame readFrame accepts, far above any proof and above the verifying key of a
+// circuit with millions of public inputs, so a corrupted length header is rejected instead of allocated.
+const MaxFrameSize = 1 << 28
+
+// WriteProofBatch writes proofs into fn as a sequence of length-prefixed frames.
+func WriteProofBatch(proofs []groth16.Proof, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		for i, proof := range proofs {
+			if err := writeProofFrame(w, proof); err != nil {
+				return fmt.Errorf("failed to write proof %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+}
+
+// ReadProofBatch restores the BN254 proofs written by WriteProofBatch.
+func ReadProofBatch(fn string) ([]groth16.Proof, error) {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open proof batch file %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var proofs []groth16.Proof
+	for {
+		proof, err := readProofFrame(f)
+		if errors.Is(err, io.EOF) {
+			return proofs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read proof %d from %s: %w", len(proofs), fn, err)
+		}
+		proofs = append(proofs, proof)
+	}
+}
+
+// AppendProof appends proof to fn as one more frame in the WriteProofBatch format, creating fn if it does not
+// exist, so ReadProofBatch decodes everything appended so far. Unlike WriteProofBatch the write is not atomic:
+// a crash mid-append leaves a truncated last frame that ReadProofBatch reports as an error. Only one writer
+// may append to a given file at a time.
+func AppendProof(proof groth16.Proof, fn string) error {
+	frame, err := encodeProofFrame(proof)
+	if err != nil {
+		return fmt.Errorf("failed to serialize proof: %w", err)
+	}
+	fn, err = checkBaseDir(fn)
+	if err != nil {
+		return err
+	}
+	if DryRun != nil {
+		return DryRun.record(fn, DefaultFileMode, func(w io.Writer) error {
+			_, err := w.Write(frame)
+			return err
+		})
+	}
+
+	if err := CheckOrCreateDir(fn); err != nil {
+		return err
+	}
+
+	appender, ok := DefaultFilesystem.(appendFilesystem)
+	if !ok {
+		return fmt.Errorf("filesystem %T does not support appending to %s", DefaultFilesystem, fn)
+	}
+	f, err := appender.OpenAppend(fn, DefaultFileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open proof batch file %s: %w", fn, err)
+	}
+
+	if _, err := f.Write(frame); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to append proof to %s: %w", fn, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close proof batch file %s: %w", fn, err)
+	}
+
+	return nil
+}
+
+func writeProofFrame(w io.Writer, proof groth16.Proof) error {
+	frame, err := encodeProofFrame(proof)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(frame)
+	return err
+}
+
+// encodeProofFrame returns the header and body of proof's frame in a single buffer.
+func encodeProofFrame(proof groth16.Proof) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, frameHeaderLen))
+	if _, err := proof.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+
+	frame := buf.Bytes()
+	binary.BigEndian.PutUint32(frame[:frameHeaderLen], uint32(len(frame)-frameHeaderLen))
+	return frame, nil
+}
+
+// readProofFrame returns io.EOF only when r is exhausted exactly at a frame boundary.
+func readProofFrame(r io.Reader) (groth16.Proof, error) {
+	frame, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+
+	br := bytes.NewReader(frame)
+	proof, n, err := readBN254Proof(br, func() int64 { return int64(br.Len()) })
+	if err != nil {
+		return nil, err
+	}
+	if n != int64(len(frame)) {
+		return nil, fmt.Errorf("frame holds %d bytes but the proof consumed %d", len(frame), n)
+	}
+
+	return proof, nil
+}
+
+// writeFrame writes data prefixed with its big-endian uint32 length.
+func writeFrame(w io.Writer, data []byte) error {
+	var header [frameHeaderLen]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readFrame reads one length-prefixed frame, returning io.EOF only when r is exhausted at a frame boundary.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [frameHeaderLen]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("truncated frame header: %w", err)
+		}
+		return nil, err
+	}
+
+	n := binary.BigEndian.Uint32(header[:])
+	if n > MaxFrameSize {
+		return nil, fmt.Errorf("%w: frame of %d bytes, limit is %d", ErrFileTooLarge, n, MaxFrameSize)
+	}
+	// grow the frame as its bytes arrive, so a length past the end of r never allocates more than r holds
+	var frame bytes.Buffer
+	if _, err := io.CopyN(&frame, r, int64(n)); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("truncated frame of %d bytes: %w", n, io.ErrUnexpectedEOF)
+		}
+		return nil, err
+	}
+
+	return frame.Bytes(), nil
+}
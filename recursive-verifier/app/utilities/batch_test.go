@@ -0,0 +1,119 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+func Test_ProofBatchRoundTrip(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "proofs.batch")
+	if err := WriteProofBatch([]groth16.Proof{proof, proof, proof}, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	proofs, err := ReadProofBatch(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proofs) != 3 {
+		t.Fatalf("expected 3 proofs, got %d", len(proofs))
+	}
+	for _, p := range proofs {
+		if err := groth16.Verify(p, vk, publicWitness); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fn, data[:len(data)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofBatch(fn); err == nil {
+		t.Fatal("expected an error for a truncated frame")
+	}
+}
+
+func Test_AppendProof(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "out", "proofs.batch")
+	if err := WriteProofBatch([]groth16.Proof{proof}, fn); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := AppendProof(proof, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	proofs, err := ReadProofBatch(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proofs) != 3 {
+		t.Fatalf("expected 3 proofs, got %d", len(proofs))
+	}
+	for _, p := range proofs {
+		if err := groth16.Verify(p, vk, publicWitness); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fresh := filepath.Join(t.TempDir(), "fresh.batch")
+	if err := AppendProof(proof, fresh); err != nil {
+		t.Fatal(err)
+	}
+	if proofs, err := ReadProofBatch(fresh); err != nil || len(proofs) != 1 {
+		t.Fatalf("expected 1 proof in a freshly created batch, got %d (%v)", len(proofs), err)
+	}
+}
+
+func Test_ReadFrame_BoundsLength(t *testing.T) {
+	huge := []byte{0xff, 0xff, 0xff, 0xff, 1, 2, 3}
+	if _, err := readFrame(bytes.NewReader(huge)); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected a length above MaxFrameSize to be rejected, got %v", err)
+	}
+
+	var truncated [frameHeaderLen]byte
+	binary.BigEndian.PutUint32(truncated[:], MaxFrameSize)
+	if _, err := readFrame(bytes.NewReader(append(truncated[:], 1, 2, 3))); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a length past the end of the input to be reported as truncated, got %v", err)
+	}
+
+	frame, err := readFrame(bytes.NewReader([]byte{0, 0, 0, 2, 7, 8}))
+	if err != nil || !bytes.Equal(frame, []byte{7, 8}) {
+		t.Fatalf("expected frame [7 8], got %v (%v)", frame, err)
+	}
+}
+
+func Test_ReadProofBatch_BoundsCommitmentCount(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	frame, err := encodeProofFrame(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the commitment count follows the compressed Ar, Bs and Krs
+	offset := frameHeaderLen + 2*bn254.SizeOfG1AffineCompressed + bn254.SizeOfG2AffineCompressed
+	binary.BigEndian.PutUint32(frame[offset:], 0x7fffffff)
+
+	fn := filepath.Join(t.TempDir(), "proofs.batch")
+	if err := os.WriteFile(fn, frame, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofBatch(fn); !errors.Is(err, errCommitmentCount) {
+		t.Fatalf("expected the commitment count to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// File names of the artifacts written by WriteSolidityBundle.
+const (
+	BundleVerifierFile = "Verifier.sol"
+	BundleProofFile    = "proof.json"
+	BundleInputFile    = "input.json"
+)
+
+// WriteSolidityBundle writes everything a Solidity developer needs to verify proof on chain into outDir:
+// the verifier contract as Verifier.sol, the verifyProof calldata as proof.json and the public inputs as
+// input.json. The three parts are serialized before anything is written, then each one is written with
+// WriteAtomic, so DryRun and DefaultObserver apply. Either all three files are written or, on any failure,
+// outDir is left as it was: the files of an earlier bundle already replaced are restored from a copy read
+// into memory before they were overwritten, and new files are removed.
+// opts apply to the calldata, e.g. WithVerifyBeforeExport(vk) to refuse bundling a proof that does not verify.
+func WriteSolidityBundle(vk groth16.VerifyingKey, proof groth16.Proof, publicWitness witness.Witness, outDir string, opts ...SolidityOption) error {
+	parts := []struct {
+		name    string
+		writeFn func(io.Writer) error
+	}{
+		{BundleVerifierFile, func(w io.Writer) error { return WriteVkInSolidityTo(w, vk) }},
+		{BundleProofFile, func(w io.Writer) error { return WriteVerifyCalldataTo(w, proof, publicWitness, opts...) }},
+		{BundleInputFile, func(w io.Writer) error { return WritePublicWitnessInJsonTo(w, publicWitness) }},
+	}
+
+	rendered := make([][]byte, len(parts))
+	for i, part := range parts {
+		var buf bytes.Buffer
+		if err := part.writeFn(&buf); err != nil {
+			return fmt.Errorf("failed to write %s: %w", filepath.Join(outDir, part.name), err)
+		}
+		rendered[i] = buf.Bytes()
+	}
+
+	backups := make([]bundleBackup, 0, len(parts))
+	for i, part := range parts {
+		fn := filepath.Join(outDir, part.name)
+		backup, err := backupBundlePart(fn)
+		if err != nil {
+			return errors.Join(err, restoreBundle(backups))
+		}
+		if err := WriteAtomic(fn, func(w io.Writer) error {
+			_, err := w.Write(rendered[i])
+			return err
+		}); err != nil {
+			return errors.Join(err, restoreBundle(backups))
+		}
+		backups = append(backups, backup)
+	}
+
+	return nil
+}
+
+// bundleBackup is the state of a bundle file before WriteSolidityBundle replaced it.
+type bundleBackup struct {
+	fn      string
+	existed bool
+	data    []byte
+	mode    fs.FileMode
+}
+
+// backupBundlePart reads fn, if it exists, so that restoreBundle can put it back. Nothing is read in dry-run
+// mode, where nothing is replaced.
+func backupBundlePart(fn string) (bundleBackup, error) {
+	backup := bundleBackup{fn: fn}
+	if DryRun != nil {
+		return backup, nil
+	}
+	info, err := DefaultFilesystem.Stat(fn)
+	if errors.Is(err, fs.ErrNotExist) {
+		return backup, nil
+	}
+	if err != nil {
+		return backup, fmt.Errorf("failed to stat %s: %w", fn, err)
+	}
+	if backup.data, err = readFile(fn); err != nil {
+		return backup, fmt.Errorf("failed to back up %s: %w", fn, err)
+	}
+	backup.existed, backup.mode = true, info.Mode().Perm()
+	return backup, nil
+}
+
+// restoreBundle undoes the writes of backups, in reverse order.
+func restoreBundle(backups []bundleBackup) error {
+	if DryRun != nil {
+		return nil
+	}
+	var errs []error
+	for i := len(backups) - 1; i >= 0; i-- {
+		b := backups[i]
+		var err error
+		if b.existed {
+			err = WriteAtomicWithMode(b.fn, b.mode, func(w io.Writer) error {
+				_, err := w.Write(b.data)
+				return err
+			})
+		} else {
+			err = DefaultFilesystem.Remove(b.fn)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to restore %s: %w", b.fn, err))
+		}
+	}
+	return errors.Join(errs...)
+}
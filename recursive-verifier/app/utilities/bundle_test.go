@@ -0,0 +1,178 @@
+package utilities
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/solidity"
+)
+
+func Test_WriteSolidityBundle(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	outDir := filepath.Join(t.TempDir(), "bundle")
+	if err := WriteSolidityBundle(vk, proof, publicWitness, outDir); err != nil {
+		t.Fatal(err)
+	}
+
+	verifier, err := os.ReadFile(filepath.Join(outDir, BundleVerifierFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(verifier), "function verifyProof") {
+		t.Fatal("expected Verifier.sol to hold the verifier contract")
+	}
+
+	data, err := os.ReadFile(filepath.Join(outDir, BundleProofFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var calldata map[string][]string
+	if err := json.Unmarshal(data, &calldata); err != nil {
+		t.Fatal(err)
+	}
+	if len(calldata["proof"]) != ProofWordCount {
+		t.Fatalf("expected %d proof words, got %d", ProofWordCount, len(calldata["proof"]))
+	}
+
+	input, err := os.ReadFile(filepath.Join(outDir, BundleInputFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(input) != `["9"]` {
+		t.Fatalf("unexpected input.json %s", input)
+	}
+}
+
+func Test_WriteSolidityBundle_CleansUpOnFailure(t *testing.T) {
+	_, vk, publicWitness := genTestProof(t)
+	blsProof, _, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+
+	outDir := t.TempDir()
+	if err := WriteSolidityBundle(vk, blsProof, publicWitness, outDir); err == nil {
+		t.Fatal("expected the calldata step to reject a BLS12-381 proof")
+	}
+	entries, err := os.ReadDir(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no files after a failed bundle, found %d", len(entries))
+	}
+}
+
+// renameFailingFilesystem fails every rename to a file named fail, like a quota hit on the last part.
+type renameFailingFilesystem struct {
+	*MemFilesystem
+	fail string
+}
+
+func (f renameFailingFilesystem) Rename(oldpath, newpath string) error {
+	if filepath.Base(newpath) == f.fail {
+		return errors.New("disk quota exceeded")
+	}
+	return f.MemFilesystem.Rename(oldpath, newpath)
+}
+
+func Test_WriteSolidityBundle_RestoresOnFailure(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	mem := NewMemFilesystem()
+	DefaultFilesystem = renameFailingFilesystem{MemFilesystem: mem, fail: BundleInputFile}
+	defer func() { DefaultFilesystem = OSFilesystem{} }()
+
+	outDir := "bundle"
+	if err := mem.MkdirAll(outDir, DefaultDirMode); err != nil {
+		t.Fatal(err)
+	}
+	verifierFn := filepath.Join(outDir, BundleVerifierFile)
+	f, err := mem.Create(verifierFn, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("previous verifier")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteSolidityBundle(vk, proof, publicWitness, outDir); err == nil {
+		t.Fatal("expected the failing rename of input.json to fail the bundle")
+	}
+	data, err := readFile(verifierFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "previous verifier" {
+		t.Fatalf("expected the previous Verifier.sol to be restored, got %q", data)
+	}
+	if info, err := mem.Stat(verifierFn); err != nil || info.Mode() != 0o600 {
+		t.Fatalf("expected the restored Verifier.sol to keep its mode, got %v (%v)", info, err)
+	}
+	if got, want := mem.Files(), []string{verifierFn}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected only the previous bundle to remain, got %v", got)
+	}
+}
+
+func Test_WriteSolidityBundle_DryRun(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	DryRun = &DryRunRecorder{}
+	defer func() { DryRun = nil }()
+
+	outDir := filepath.Join(t.TempDir(), "bundle")
+	if err := WriteSolidityBundle(vk, proof, publicWitness, outDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(outDir); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be written in dry-run mode, got %v", err)
+	}
+	if n := len(DryRun.Manifest()); n != 3 {
+		t.Fatalf("expected 3 recorded files, got %d", n)
+	}
+}
+
+func Test_WriteSolidityBundle_VerifyBeforeExport(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	_, otherVk, _ := genTestProof(t)
+
+	outDir := filepath.Join(t.TempDir(), "bundle")
+	if err := WriteSolidityBundle(vk, proof, publicWitness, outDir, WithVerifyBeforeExport(vk)); err != nil {
+		t.Fatal(err)
+	}
+
+	rejectedDir := filepath.Join(t.TempDir(), "rejected")
+	err := WriteSolidityBundle(vk, proof, publicWitness, rejectedDir, WithVerifyBeforeExport(otherVk))
+	if err == nil || !strings.Contains(err.Error(), "proof verification failed") {
+		t.Fatalf("expected a verification failure, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(rejectedDir, BundleProofFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no calldata for an invalid proof, got %v", err)
+	}
+
+	calldataFn := filepath.Join(t.TempDir(), "calldata.json")
+	if err := WriteVerifyCalldata(proof, publicWitness, calldataFn, WithVerifyBeforeExport(otherVk)); err == nil {
+		t.Fatal("expected WriteVerifyCalldata to refuse an invalid proof")
+	}
+	if _, err := os.Stat(calldataFn); !os.IsNotExist(err) {
+		t.Fatalf("expected no calldata file, got %v", err)
+	}
+	// the contract derives commitment inputs with keccak256, a proof made with gnark's default hash would revert
+	defaultProof, defaultVk, defaultWitness := genCommitProof(t)
+	if err := WriteVerifyCalldata(defaultProof, defaultWitness, calldataFn, WithVerifyBeforeExport(defaultVk)); err == nil {
+		t.Fatal("expected WriteVerifyCalldata to refuse a default hash-to-field proof")
+	}
+	if _, err := os.Stat(calldataFn); !os.IsNotExist(err) {
+		t.Fatalf("expected no calldata file, got %v", err)
+	}
+	solidityProof, commitVk, commitWitness := genCommitProof(t, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+	if err := WriteVerifyCalldata(solidityProof, commitWitness, calldataFn, WithVerifyBeforeExport(commitVk)); err != nil {
+		t.Fatal(err)
+	}
+}
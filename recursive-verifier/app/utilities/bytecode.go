@@ -0,0 +1,125 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// SolcPath is the solc binary WriteVerifierBytecode compiles with, looked up in PATH unless it holds a path
+// separator.
+var SolcPath = "solc"
+
+// MinSolcVersion is the oldest solc WriteVerifierBytecode accepts: the exported verifier uses memory-safe
+// assembly blocks, introduced in 0.8.13.
+const MinSolcVersion = "0.8.13"
+
+// ErrSolcUnavailable is returned when SolcPath cannot be found or run.
+var ErrSolcUnavailable = errors.New("solc is not available")
+
+var solcVersionPattern = regexp.MustCompile(`Version: (\d+)\.(\d+)\.(\d+)`)
+
+// WriteVerifierBytecode compiles the verifier contract of vk, exported as WriteVkInSolidityWithOptions does
+// with opts, with the solc at SolcPath and writes its creation bytecode to fn as 0x prefixed hex, ready to
+// be sent as the data of a deployment transaction. solc must be at least MinSolcVersion. The contract is
+// compiled with solc's default settings, without the optimizer.
+func WriteVerifierBytecode(vk groth16.VerifyingKey, fn string, opts ...SolidityOption) error {
+	bytecode, err := VerifierBytecode(vk, opts...)
+	if err != nil {
+		return err
+	}
+	return WriteAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, bytecode)
+		return err
+	})
+}
+
+// VerifierBytecode returns the creation bytecode WriteVerifierBytecode writes.
+func VerifierBytecode(vk groth16.VerifyingKey, opts ...SolidityOption) (string, error) {
+	solc, err := exec.LookPath(SolcPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSolcUnavailable, err)
+	}
+	if err := checkSolcVersion(solc); err != nil {
+		return "", err
+	}
+
+	var source bytes.Buffer
+	if err := WriteVkInSolidityWithOptionsTo(&source, vk, opts...); err != nil {
+		return "", err
+	}
+	contractName := "Verifier"
+	if cfg := newSolidityConfig(opts); cfg.contractName != "" {
+		contractName = cfg.contractName
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(solc, "--combined-json", "bin", "-")
+	cmd.Stdin = &source
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("solc failed to compile the verifier: %w\n%s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	var out struct {
+		Contracts map[string]struct {
+			Bin string `json:"bin"`
+		} `json:"contracts"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return "", fmt.Errorf("failed to parse solc output: %w", err)
+	}
+	// contracts are keyed by <source>:<name>, the source being <stdin>
+	for key, contract := range out.Contracts {
+		if strings.HasSuffix(key, ":"+contractName) {
+			if contract.Bin == "" {
+				return "", fmt.Errorf("solc returned no bytecode for contract %s", contractName)
+			}
+			return "0x" + contract.Bin, nil
+		}
+	}
+	return "", fmt.Errorf("solc output holds no contract %s", contractName)
+}
+
+// checkSolcVersion returns an error if the solc binary is older than MinSolcVersion.
+func checkSolcVersion(solc string) error {
+	out, err := exec.Command(solc, "--version").Output()
+	if err != nil {
+		return fmt.Errorf("%w: failed to run %s --version: %w", ErrSolcUnavailable, solc, err)
+	}
+	version, err := parseSolcVersion(string(out))
+	if err != nil {
+		return err
+	}
+	minVersion, _ := parseSolcVersion("Version: " + MinSolcVersion)
+	for i := range version {
+		if version[i] != minVersion[i] {
+			if version[i] < minVersion[i] {
+				return fmt.Errorf("solc %d.%d.%d is too old, at least %s is required", version[0], version[1], version[2], MinSolcVersion)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// parseSolcVersion extracts the major, minor and patch numbers of the output of solc --version.
+func parseSolcVersion(out string) ([3]int, error) {
+	m := solcVersionPattern.FindStringSubmatch(out)
+	if m == nil {
+		return [3]int{}, fmt.Errorf("unrecognized solc version output %q", strings.TrimSpace(out))
+	}
+	var version [3]int
+	for i := range version {
+		version[i], _ = strconv.Atoi(m[i+1])
+	}
+	return version, nil
+}
@@ -0,0 +1,95 @@
+package utilities
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func Test_WriteVerifierBytecode(t *testing.T) {
+	if _, err := exec.LookPath(SolcPath); err != nil {
+		t.Skip("solc not found, skipping compilation of the verifier")
+	}
+	_, vk, _ := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "Verifier.bin")
+	if err := WriteVerifierBytecode(vk, fn); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "0x") || len(data) < 100 {
+		t.Fatalf("expected hex creation bytecode, got %q", data)
+	}
+}
+
+func Test_WriteVerifierBytecodeSolc(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	dir := t.TempDir()
+	defer func(path string) { SolcPath = path }(SolcPath)
+
+	SolcPath = filepath.Join(dir, "missing-solc")
+	if err := WriteVerifierBytecode(vk, filepath.Join(dir, "Verifier.bin")); !errors.Is(err, ErrSolcUnavailable) {
+		t.Fatalf("expected ErrSolcUnavailable, got %v", err)
+	}
+
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake solc is a shell script")
+	}
+	SolcPath = filepath.Join(dir, "old-solc")
+	script := "#!/bin/sh\necho 'solc, the solidity compiler commandline interface'\necho 'Version: 0.8.12+commit.f00d7308.Linux.g++'\n"
+	if err := os.WriteFile(SolcPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	err := WriteVerifierBytecode(vk, filepath.Join(dir, "Verifier.bin"))
+	if err == nil || !strings.Contains(err.Error(), "too old") {
+		t.Fatalf("expected an old solc to be rejected, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Verifier.bin")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected no bytecode file to be written")
+	}
+
+	// a solc answering with canned combined json, checking it is handed the exported source
+	SolcPath = filepath.Join(dir, "fake-solc")
+	script = `#!/bin/sh
+if [ "$1" = "--version" ]; then echo 'Version: 0.8.26+commit.8a97fa7a.Linux.g++'; exit 0; fi
+grep -q 'pragma solidity' || exit 1
+echo '{"contracts":{"<stdin>:Pairing":{"bin":"00"},"<stdin>:Verifier":{"bin":"6080604052"}}}'
+`
+	if err := os.WriteFile(SolcPath, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, "Verifier.bin")
+	if err := WriteVerifierBytecode(vk, fn); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "0x6080604052" {
+		t.Fatalf("unexpected bytecode %q", data)
+	}
+	if err := WriteVerifierBytecode(vk, fn, WithContractName("Other")); err == nil {
+		t.Fatal("expected a missing contract in the solc output to be rejected")
+	}
+}
+
+func Test_ParseSolcVersion(t *testing.T) {
+	version, err := parseSolcVersion("solc, the solidity compiler commandline interface\nVersion: 0.8.26+commit.8a97fa7a.Linux.g++\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != [3]int{0, 8, 26} {
+		t.Fatalf("unexpected version %v", version)
+	}
+	if _, err := parseSolcVersion("garbage"); err == nil {
+		t.Fatal("expected unrecognized output to be rejected")
+	}
+}
@@ -0,0 +1,79 @@
+package utilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// FeltsPerWord is the number of felts a 256 bit coordinate is split into in Cairo calldata.
+const FeltsPerWord = 2
+
+// cairoFeltMask masks the low 128 bits of a coordinate.
+var cairoFeltMask = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// WriteProofInCairoCalldata writes the calldata of a BN254 proof for a Cairo Groth16 verifier on StarkNet to fn,
+// as a JSON array of 0x prefixed hex felts. A felt holds less than 252 bits, so every 256 bit coordinate is
+// passed as a Cairo u256, which serializes as two felts: its low 128 bits first, then its high 128 bits,
+// i.e. v = low + high * 2^128. The array is the Cairo serialization of the proof struct:
+//
+//	Ar.X, Ar.Y, Bs.X.A0, Bs.X.A1, Bs.Y.A0, Bs.Y.A1, Krs.X, Krs.Y,
+//	len(Commitments), Commitments[0].X, Commitments[0].Y, ..., CommitmentPok.X, CommitmentPok.Y
+//
+// each coordinate but the array length taking two felts. Unlike the Solidity export, Fp2 coordinates keep
+// gnark's order, real part A0 first, the swap of NormalizeG2ForEVM being specific to the EVM precompile. The
+// commitments are a Cairo Array, prefixed by their count, and the CommitmentPok point is only written when
+// the proof has commitments.
+func WriteProofInCairoCalldata(proof groth16.Proof, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteProofInCairoCalldataTo(w, proof)
+	})
+}
+
+// WriteProofInCairoCalldataTo streams the Cairo calldata of proof to w.
+func WriteProofInCairoCalldataTo(w io.Writer, proof groth16.Proof) error {
+	felts, err := proofToCairoFelts(proof)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(bigIntSliceToStrings(felts, Hex))
+	if err != nil {
+		return fmt.Errorf("failed to marshal cairo calldata: %w", err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// proofToCairoFelts returns the felts of WriteProofInCairoCalldata, checking every coordinate as the Solidity
+// export does.
+func proofToCairoFelts(proof groth16.Proof) ([]*big.Int, error) {
+	proofWords, commitments, pok, err := proofToSolidityWords(proof)
+	if err != nil {
+		return nil, err
+	}
+
+	words := []*big.Int{
+		proofWords[0], proofWords[1],
+		// back from the EVM order (X.A1, X.A0, Y.A1, Y.A0)
+		proofWords[3], proofWords[2], proofWords[5], proofWords[4],
+		proofWords[6], proofWords[7],
+	}
+	felts := splitCairoWords(nil, words)
+	felts = append(felts, big.NewInt(int64(len(commitments)/WordsPerCommitment)))
+	felts = splitCairoWords(felts, commitments)
+	if len(commitments) > 0 {
+		felts = splitCairoWords(felts, pok[:])
+	}
+	return felts, nil
+}
+
+// splitCairoWords appends the low and high 128 bits of every word to felts.
+func splitCairoWords(felts, words []*big.Int) []*big.Int {
+	for _, v := range words {
+		felts = append(felts, new(big.Int).And(v, cairoFeltMask), new(big.Int).Rsh(v, 128))
+	}
+	return felts
+}
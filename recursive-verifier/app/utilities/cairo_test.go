@@ -0,0 +1,100 @@
+package utilities
+
+import (
+	"encoding/json"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+func Test_WriteProofInCairoCalldata(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &committedSquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&committedSquareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _, _ := genTestProof(t)
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		name  string
+		proof groth16.Proof
+	}{
+		{"plain", plain},
+		{"committed", committed},
+	} {
+		fn := filepath.Join(dir, tc.name+".json")
+		if err := WriteProofInCairoCalldata(tc.proof, fn); err != nil {
+			t.Fatal(err)
+		}
+		data, err := readFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var hexFelts []string
+		if err := json.Unmarshal(data, &hexFelts); err != nil {
+			t.Fatal(err)
+		}
+		felts := make([]*big.Int, len(hexFelts))
+		for i, s := range hexFelts {
+			v, ok := new(big.Int).SetString(s, 0)
+			if !ok || v.BitLen() > 128 {
+				t.Fatalf("%s: felt %d is not a 128 bit value: %s", tc.name, i, s)
+			}
+			felts[i] = v
+		}
+
+		// rebuild every u256 from its low and high felts
+		word := func(i int) *big.Int {
+			return new(big.Int).Add(felts[i], new(big.Int).Lsh(felts[i+1], 128))
+		}
+		p := tc.proof.(*groth16_bn254.Proof)
+		want := []*big.Int{
+			p.Ar.X.BigInt(new(big.Int)), p.Ar.Y.BigInt(new(big.Int)),
+			p.Bs.X.A0.BigInt(new(big.Int)), p.Bs.X.A1.BigInt(new(big.Int)),
+			p.Bs.Y.A0.BigInt(new(big.Int)), p.Bs.Y.A1.BigInt(new(big.Int)),
+			p.Krs.X.BigInt(new(big.Int)), p.Krs.Y.BigInt(new(big.Int)),
+		}
+		for i, v := range want {
+			if word(2*i).Cmp(v) != 0 {
+				t.Fatalf("%s: word %d is %s, expected %s", tc.name, i, word(2*i), v)
+			}
+		}
+		n := len(p.Commitments)
+		if felts[16].Int64() != int64(n) {
+			t.Fatalf("%s: expected %d commitments, got %s", tc.name, n, felts[16])
+		}
+		wantLen := 17
+		if n > 0 {
+			wantLen += 4*n + 4
+			if word(17).Cmp(p.Commitments[0].X.BigInt(new(big.Int))) != 0 || word(21).Cmp(p.CommitmentPok.X.BigInt(new(big.Int))) != 0 {
+				t.Fatalf("%s: unexpected commitment felts", tc.name)
+			}
+		}
+		if len(felts) != wantLen {
+			t.Fatalf("%s: expected %d felts, got %d", tc.name, wantLen, len(felts))
+		}
+	}
+
+	bls, _, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if err := WriteProofInCairoCalldata(bls, filepath.Join(dir, "bls.json")); err == nil {
+		t.Fatal("expected a BLS12-381 proof to be rejected")
+	}
+}
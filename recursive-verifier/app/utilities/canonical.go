@@ -0,0 +1,131 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// CanonicalProofVersion is the first byte of CanonicalProofBytes, bumped on any change of the encoding.
+const CanonicalProofVersion byte = 1
+
+// CanonicalProofBytes encodes a BN254 proof in a fixed layout that does not follow gnark's WriteTo, so the
+// bytes stay stable when gnark's own serialization changes:
+//
+//	version (1 byte, CanonicalProofVersion)
+//	Ar (32 bytes) || Bs (64 bytes) || Krs (32 bytes)
+//	number of commitments (uint32, big-endian) || commitments (32 bytes each)
+//	CommitmentPok (32 bytes)
+//
+// Points are compressed: the big-endian X coordinate, X.A1 then X.A0 for G2, with the two most significant
+// bits of the first byte set to 0b10 when Y is the smaller of Y and -Y, 0b11 when it is the larger and
+// 0b01 for the point at infinity, whose remaining bits are zero. Every valid proof has exactly one encoding.
+func CanonicalProofBytes(proof groth16.Proof) ([]byte, error) {
+	_proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return nil, fmt.Errorf("unsupported proof type %T, only BN254 is supported", proof)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte(CanonicalProofVersion)
+	writeG1 := func(p *bn254.G1Affine) {
+		b := p.Bytes()
+		buf.Write(b[:])
+	}
+
+	writeG1(&_proof.Ar)
+	bs := _proof.Bs.Bytes()
+	buf.Write(bs[:])
+	writeG1(&_proof.Krs)
+
+	var count [4]byte
+	binary.BigEndian.PutUint32(count[:], uint32(len(_proof.Commitments)))
+	buf.Write(count[:])
+	for i := range _proof.Commitments {
+		writeG1(&_proof.Commitments[i])
+	}
+	writeG1(&_proof.CommitmentPok)
+
+	return buf.Bytes(), nil
+}
+
+// ProofFromCanonicalBytes decodes the output of CanonicalProofBytes. It rejects unknown versions, points not
+// on the curve or its subgroup, non-canonical point encodings and trailing bytes, so any accepted input is
+// exactly what CanonicalProofBytes returns for the decoded proof.
+func ProofFromCanonicalBytes(data []byte) (groth16.Proof, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty canonical proof")
+	}
+	if data[0] != CanonicalProofVersion {
+		return nil, fmt.Errorf("unsupported canonical proof version %d, expected %d", data[0], CanonicalProofVersion)
+	}
+	rest := data[1:]
+	next := func(name string, n int) ([]byte, error) {
+		if len(rest) < n {
+			return nil, fmt.Errorf("truncated canonical proof: missing %s", name)
+		}
+		b := rest[:n]
+		rest = rest[n:]
+		return b, nil
+	}
+
+	var proof groth16_bn254.Proof
+	readG1 := func(name string, p *bn254.G1Affine) error {
+		b, err := next(name, bn254.SizeOfG1AffineCompressed)
+		if err != nil {
+			return err
+		}
+		if _, err := p.SetBytes(b); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+		if e := p.Bytes(); !bytes.Equal(e[:], b) {
+			return fmt.Errorf("non-canonical encoding of %s", name)
+		}
+		return nil
+	}
+
+	if err := readG1("Ar", &proof.Ar); err != nil {
+		return nil, err
+	}
+	b, err := next("Bs", bn254.SizeOfG2AffineCompressed)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := proof.Bs.SetBytes(b); err != nil {
+		return nil, fmt.Errorf("invalid Bs: %w", err)
+	}
+	if e := proof.Bs.Bytes(); !bytes.Equal(e[:], b) {
+		return nil, fmt.Errorf("non-canonical encoding of Bs")
+	}
+	if err := readG1("Krs", &proof.Krs); err != nil {
+		return nil, err
+	}
+
+	count, err := next("commitment count", 4)
+	if err != nil {
+		return nil, err
+	}
+	nbCommitments := binary.BigEndian.Uint32(count)
+	// reject counts the input cannot hold before allocating for them
+	if uint64(nbCommitments)*bn254.SizeOfG1AffineCompressed > uint64(len(rest)) {
+		return nil, fmt.Errorf("truncated canonical proof: %d commitments do not fit in %d bytes", nbCommitments, len(rest))
+	}
+	proof.Commitments = make([]bn254.G1Affine, nbCommitments)
+	for i := range proof.Commitments {
+		if err := readG1(fmt.Sprintf("Commitments[%d]", i), &proof.Commitments[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := readG1("CommitmentPok", &proof.CommitmentPok); err != nil {
+		return nil, err
+	}
+
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("canonical proof holds %d trailing bytes", len(rest))
+	}
+	return &proof, nil
+}
@@ -0,0 +1,76 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+func Test_CanonicalProofBytes(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	data, err := CanonicalProofBytes(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := CanonicalProofBytes(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, again) {
+		t.Fatal("expected the encoding to be deterministic")
+	}
+	if want := 1 + 32 + 64 + 32 + 4 + 32; len(data) != want {
+		t.Fatalf("expected %d bytes, got %d", want, len(data))
+	}
+
+	decoded, err := ProofFromCanonicalBytes(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(decoded, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	reencoded, err := CanonicalProofBytes(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, reencoded) {
+		t.Fatal("expected the decoded proof to encode to the same bytes")
+	}
+
+	if _, err := ProofFromCanonicalBytes(append(data, 0)); err == nil {
+		t.Fatal("expected trailing bytes to be rejected")
+	}
+	if _, err := ProofFromCanonicalBytes(data[:len(data)-1]); err == nil {
+		t.Fatal("expected a truncated proof to be rejected")
+	}
+	bad := bytes.Clone(data)
+	bad[0] = CanonicalProofVersion + 1
+	if _, err := ProofFromCanonicalBytes(bad); err == nil {
+		t.Fatal("expected an unknown version to be rejected")
+	}
+}
+
+// Test_CanonicalProofBytes_Stable pins the encoding of a proof made of generators, so a change of the
+// layout or of gnark-crypto's point compression fails here instead of invalidating signatures.
+func Test_CanonicalProofBytes_Stable(t *testing.T) {
+	_, _, g1, g2 := bn254.Generators()
+	proof := &groth16_bn254.Proof{Ar: g1, Bs: g2, Krs: g1, Commitments: []bn254.G1Affine{g1}, CommitmentPok: g1}
+
+	data, err := CanonicalProofBytes(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g1Hex := "8000000000000000000000000000000000000000000000000000000000000001"
+	g2Hex := "998e9393920d483a7260bfb731fb5d25f1aa493335a9e71297e485b7aef312c21800deef121f1e76426a00665e5c4479674322d4f75edadd46debd5cd992f6ed"
+	want := "01" + g1Hex + g2Hex + g1Hex + "00000001" + g1Hex + g1Hex
+	if got := hex.EncodeToString(data); got != want {
+		t.Fatalf("canonical encoding changed:\ngot  %s\nwant %s", got, want)
+	}
+}
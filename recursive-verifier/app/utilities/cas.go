@@ -0,0 +1,47 @@
+package utilities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+// WriteContentAddressed stores the serialization of src at baseDir/<sha256 hex> and returns that path.
+// If a file with the digest already exists nothing is written, so identical artifacts such as proving keys
+// shared between circuits are kept once. src is serialized once to hash it and once per write attempt,
+// instead of buffering potentially gigabytes in memory, so its WriteTo must be deterministic.
+func WriteContentAddressed(src io.WriterTo, baseDir string, opts ...WriteOption) (string, error) {
+	h := sha256.New()
+	if _, err := src.WriteTo(h); err != nil {
+		return "", fmt.Errorf("failed to hash artifact: %w", err)
+	}
+	digest := hex.EncodeToString(h.Sum(nil))
+	fn := filepath.Join(baseDir, digest)
+
+	exists, err := FileExists(fn)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return fn, nil
+	}
+
+	err = WriteAtomic(fn, func(w io.Writer) error {
+		// a new hash on every attempt, WithRetry calls this again after a failed one
+		rehash := sha256.New()
+		if _, err := src.WriteTo(io.MultiWriter(w, rehash)); err != nil {
+			return err
+		}
+		if got := hex.EncodeToString(rehash.Sum(nil)); got != digest {
+			return fmt.Errorf("artifact serialization is not deterministic: hashed %s, wrote %s", digest, got)
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		return "", err
+	}
+
+	return fn, nil
+}
@@ -0,0 +1,87 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_WriteContentAddressed(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	baseDir := filepath.Join(t.TempDir(), "cas")
+
+	fn, err := WriteContentAddressed(vk, baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(fn) != baseDir || len(filepath.Base(fn)) != 64 {
+		t.Fatalf("unexpected content address %s", fn)
+	}
+	restored, err := ReadVk(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal, diff := VkEqual(vk, restored); !equal {
+		t.Fatal(diff)
+	}
+
+	// an existing entry must not be rewritten
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(fn, old, old); err != nil {
+		t.Fatal(err)
+	}
+	again, err := WriteContentAddressed(vk, baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(again)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != fn || !info.ModTime().Equal(old) {
+		t.Fatal("expected the existing entry to be reused without writing")
+	}
+
+	_, otherVk, _ := genTestProof(t)
+	other, err := WriteContentAddressed(otherVk, baseDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == fn {
+		t.Fatal("expected different keys to get different addresses")
+	}
+}
+
+// renameOnceFailingFilesystem fails the first rename with a transient error, like a stale NFS handle.
+type renameOnceFailingFilesystem struct {
+	OSFilesystem
+	renames int
+}
+
+func (f *renameOnceFailingFilesystem) Rename(oldpath, newpath string) error {
+	f.renames++
+	if f.renames == 1 {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EAGAIN}
+	}
+	return f.OSFilesystem.Rename(oldpath, newpath)
+}
+
+func Test_WriteContentAddressed_Retry(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	fsys := &renameOnceFailingFilesystem{}
+	DefaultFilesystem = fsys
+	defer func() { DefaultFilesystem = OSFilesystem{} }()
+
+	fn, err := WriteContentAddressed(vk, filepath.Join(t.TempDir(), "cas"), WithRetry(2, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fsys.renames != 2 {
+		t.Fatalf("expected the write to be retried once, got %d renames", fsys.renames)
+	}
+	if _, err := ReadVk(fn); err != nil {
+		t.Fatal(err)
+	}
+}
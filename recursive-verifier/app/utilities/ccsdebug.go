@@ -0,0 +1,197 @@
+package utilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark/constraint"
+)
+
+// DefaultCcsDebugLimit is the number of constraints and coefficients WriteCcsDebugJson includes.
+const DefaultCcsDebugLimit = 1000
+
+// ccsDebug is the lossy, human readable dump written by WriteCcsDebugJson. It is not meant to be read back.
+type ccsDebug struct {
+	CircuitStats
+	Truncated    bool          `json:"truncated"`
+	Coefficients []string      `json:"coefficients"`
+	Constraints  []ccsDebugRow `json:"constraints"`
+}
+
+// ccsDebugRow is one constraint, the L, R and O sides are only set for R1CS.
+type ccsDebugRow struct {
+	Expr string         `json:"expr"`
+	L    []ccsDebugTerm `json:"l,omitempty"`
+	R    []ccsDebugTerm `json:"r,omitempty"`
+	O    []ccsDebugTerm `json:"o,omitempty"`
+}
+
+type ccsDebugTerm struct {
+	Coeff string `json:"coeff"`
+	Wire  string `json:"wire"`
+}
+
+// WriteCcsDebugJson writes a JSON dump of ccs for inspection: its CircuitStats, the coefficient table and
+// every constraint, truncated to DefaultCcsDebugLimit entries each.
+func WriteCcsDebugJson(ccs constraint.ConstraintSystem, fn string) error {
+	return WriteCcsDebugJsonLimit(ccs, fn, DefaultCcsDebugLimit)
+}
+
+// WriteCcsDebugJsonLimit is WriteCcsDebugJson including at most limit constraints and coefficients.
+// A limit <= 0 includes everything.
+func WriteCcsDebugJsonLimit(ccs constraint.ConstraintSystem, fn string, limit int) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteCcsDebugJsonTo(w, ccs, limit)
+	})
+}
+
+// WriteCcsDebugJsonTo streams the JSON dump of ccs to w.
+func WriteCcsDebugJsonTo(w io.Writer, ccs constraint.ConstraintSystem, limit int) error {
+	dump := ccsDebug{CircuitStats: CcsStats(ccs)}
+	capped := func(n int) int {
+		if limit > 0 && n > limit {
+			dump.Truncated = true
+			return limit
+		}
+		return n
+	}
+
+	dump.Coefficients = make([]string, capped(ccs.GetNbCoefficients()))
+	for i := range dump.Coefficients {
+		dump.Coefficients[i] = ccs.CoeffToString(i)
+	}
+
+	switch cs := ccs.(type) {
+	case interface{ GetR1Cs() []constraint.R1C }:
+		r1cs := cs.GetR1Cs()
+		dump.Constraints = make([]ccsDebugRow, capped(len(r1cs)))
+		for i := range dump.Constraints {
+			dump.Constraints[i] = ccsDebugRow{
+				Expr: r1cs[i].String(ccs),
+				L:    debugTerms(ccs, r1cs[i].L),
+				R:    debugTerms(ccs, r1cs[i].R),
+				O:    debugTerms(ccs, r1cs[i].O),
+			}
+		}
+	case interface{ GetSparseR1Cs() []constraint.SparseR1C }:
+		scs := cs.GetSparseR1Cs()
+		dump.Constraints = make([]ccsDebugRow, capped(len(scs)))
+		for i := range dump.Constraints {
+			dump.Constraints[i] = ccsDebugRow{Expr: scs[i].String(ccs)}
+		}
+	default:
+		return fmt.Errorf("unsupported constraint system type %T", ccs)
+	}
+
+	data, err := json.MarshalIndent(dump, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal constraint system: %w", err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+func debugTerms(r constraint.Resolver, l constraint.LinearExpression) []ccsDebugTerm {
+	terms := make([]ccsDebugTerm, len(l))
+	for i, t := range l {
+		terms[i] = ccsDebugTerm{Coeff: r.CoeffToString(int(t.CID)), Wire: r.VariableToString(int(t.VID))}
+	}
+	return terms
+}
+
+// ccsMatrices is the A, B, C matrix export written by WriteCcsMatrices.
+type ccsMatrices struct {
+	Field     string        `json:"field"`
+	NbRows    int           `json:"nbRows"`
+	NbColumns int           `json:"nbColumns"`
+	NbPublic  int           `json:"nbPublic"`
+	A         []matrixEntry `json:"a"`
+	B         []matrixEntry `json:"b"`
+	C         []matrixEntry `json:"c"`
+}
+
+// matrixEntry marshals as a [row, col, "coeff"] triple.
+type matrixEntry struct {
+	Row, Col int
+	Coeff    string
+}
+
+func (e matrixEntry) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]any{e.Row, e.Col, e.Coeff})
+}
+
+// WriteCcsMatrices writes an R1CS as the sparse A, B and C matrices of A·z ∘ B·z = C·z, for R1CS tooling
+// outside of gnark. Every matrix is a JSON array of [row, col, "coeff"] triples, one row per constraint and
+// one column per wire in gnark's order: the constant one wire, the public, the secret and then the internal
+// variables, nbPublic counting the one wire. Coefficients are decimal strings reduced modulo field.
+// The export is lossy, hints, commitments and debug info are dropped, and it cannot be read back.
+func WriteCcsMatrices(ccs constraint.ConstraintSystem, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteCcsMatricesTo(w, ccs)
+	})
+}
+
+// WriteCcsMatricesTo streams the matrix export of ccs to w.
+func WriteCcsMatricesTo(w io.Writer, ccs constraint.ConstraintSystem) error {
+	cs, ok := ccs.(interface{ GetR1Cs() []constraint.R1C })
+	if !ok {
+		return fmt.Errorf("unsupported constraint system type %T, only R1CS has A, B and C matrices", ccs)
+	}
+
+	modulus := ccs.Field()
+	// the coefficient table prints small negative values as such, e.g. -1, so reduce every entry
+	coeffs := make([]string, ccs.GetNbCoefficients())
+	for i := range coeffs {
+		c, ok := new(big.Int).SetString(ccs.CoeffToString(i), 10)
+		if !ok {
+			return fmt.Errorf("failed to parse coefficient %d %q", i, ccs.CoeffToString(i))
+		}
+		coeffs[i] = c.Mod(c, modulus).String()
+	}
+
+	r1cs := cs.GetR1Cs()
+	m := ccsMatrices{
+		Field:     modulus.String(),
+		NbRows:    len(r1cs),
+		NbColumns: ccs.GetNbPublicVariables() + ccs.GetNbSecretVariables() + ccs.GetNbInternalVariables(),
+		NbPublic:  ccs.GetNbPublicVariables(),
+		A:         []matrixEntry{},
+		B:         []matrixEntry{},
+		C:         []matrixEntry{},
+	}
+	appendRow := func(dst []matrixEntry, row int, l constraint.LinearExpression) ([]matrixEntry, error) {
+		for _, t := range l {
+			if int(t.CID) >= len(coeffs) {
+				return nil, fmt.Errorf("constraint %d references coefficient %d out of %d", row, t.CID, len(coeffs))
+			}
+			if coeffs[t.CID] == "0" {
+				continue
+			}
+			dst = append(dst, matrixEntry{Row: row, Col: int(t.VID), Coeff: coeffs[t.CID]})
+		}
+		return dst, nil
+	}
+	for i, r := range r1cs {
+		var err error
+		if m.A, err = appendRow(m.A, i, r.L); err != nil {
+			return err
+		}
+		if m.B, err = appendRow(m.B, i, r.R); err != nil {
+			return err
+		}
+		if m.C, err = appendRow(m.C, i, r.O); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal constraint matrices: %w", err)
+	}
+
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,140 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	cs_bn254 "github.com/consensys/gnark/constraint/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_WriteCcsDebugJson(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCcsDebugJsonTo(&buf, ccs, 0); err != nil {
+		t.Fatal(err)
+	}
+	var dump ccsDebug
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if dump.Truncated || len(dump.Constraints) != ccs.GetNbConstraints() || len(dump.Coefficients) != ccs.GetNbCoefficients() {
+		t.Fatalf("unexpected untruncated dump: %d constraints, %d coefficients, truncated %v",
+			len(dump.Constraints), len(dump.Coefficients), dump.Truncated)
+	}
+	if dump.NbSecretVariables != 1 || dump.Constraints[0].Expr == "" || len(dump.Constraints[0].L) == 0 {
+		t.Fatalf("unexpected dump %+v", dump)
+	}
+
+	buf.Reset()
+	if err := WriteCcsDebugJsonTo(&buf, ccs, 1); err != nil {
+		t.Fatal(err)
+	}
+	dump = ccsDebug{}
+	if err := json.Unmarshal(buf.Bytes(), &dump); err != nil {
+		t.Fatal(err)
+	}
+	if !dump.Truncated || len(dump.Constraints) != 1 || len(dump.Coefficients) != 1 {
+		t.Fatalf("expected a truncated dump, got %d constraints, %d coefficients", len(dump.Constraints), len(dump.Coefficients))
+	}
+}
+
+type subCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *subCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), api.Sub(c.Y, c.X))
+	return nil
+}
+
+func Test_WriteCcsMatrices(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &subCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCcsMatricesTo(&buf, ccs); err != nil {
+		t.Fatal(err)
+	}
+	var m struct {
+		Field     string `json:"field"`
+		NbRows    int    `json:"nbRows"`
+		NbColumns int    `json:"nbColumns"`
+		NbPublic  int    `json:"nbPublic"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(buf.Bytes(), &raw); err != nil {
+		t.Fatal(err)
+	}
+	if m.NbRows != ccs.GetNbConstraints() || m.NbPublic != 2 || m.Field != ecc.BN254.ScalarField().String() {
+		t.Fatalf("unexpected header %+v", m)
+	}
+
+	// the matrices must hold for the solved wire assignment
+	fullWitness, err := frontend.NewWitness(&subCircuit{X: 3, Y: 12}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	solution, err := ccs.Solve(fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := solution.(*cs_bn254.R1CSSolution).W
+	if len(z) != m.NbColumns {
+		t.Fatalf("expected %d columns, got %d", len(z), m.NbColumns)
+	}
+	modulus := ecc.BN254.ScalarField()
+
+	product := func(key string) []*big.Int {
+		var entries [][]json.RawMessage
+		if err := json.Unmarshal(raw[key], &entries); err != nil {
+			t.Fatal(err)
+		}
+		rows := make([]*big.Int, m.NbRows)
+		for i := range rows {
+			rows[i] = new(big.Int)
+		}
+		for _, e := range entries {
+			var row, col int
+			var coeff string
+			if err := json.Unmarshal(e[0], &row); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(e[1], &col); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(e[2], &coeff); err != nil {
+				t.Fatal(err)
+			}
+			c, ok := new(big.Int).SetString(coeff, 10)
+			if !ok || c.Sign() < 0 || c.Cmp(modulus) >= 0 {
+				t.Fatalf("coefficient %q is not reduced", coeff)
+			}
+			rows[row].Add(rows[row], c.Mul(c, z[col].BigInt(new(big.Int))))
+		}
+		return rows
+	}
+	a, b, c := product("a"), product("b"), product("c")
+	for i := range a {
+		lhs := new(big.Int).Mul(a[i], b[i])
+		if lhs.Mod(lhs, modulus).Cmp(c[i].Mod(c[i], modulus)) != 0 {
+			t.Fatalf("constraint %d does not hold", i)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package utilities
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// WithCcsHeader makes WriteCcs and the other ccs writers prefix gnark's serialization with a CcsHeaderSize
+// byte header: the magic "gccs", the format version 1, the major, minor and patch version of the gnark build
+// that wrote it as one byte each, and the ecc.ID as a big-endian uint16. When compressed, the header is part
+// of the gzip stream. ReadCcs and friends accept both: a file starting with the magic is checked against the
+// gnark version and curve of the reading build, any other file is decoded as a headerless ccs. gnark's
+// serialization starts with its length as a little-endian uint64, which would have to exceed 4 GiB to start
+// with the magic and format version.
+func WithCcsHeader() WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.ccsHeader = true
+	}
+}
+
+// CcsHeaderSize is the length of the header written with WithCcsHeader.
+const CcsHeaderSize = 10
+
+var ccsMagic = []byte("gccs")
+
+const ccsHeaderVersion = 1
+
+func ccsHeader(ccs constraint.ConstraintSystem) ([]byte, error) {
+	curveID, ok := curveOfField(ccs)
+	if !ok {
+		return nil, fmt.Errorf("no curve has the scalar field %s of the ccs", ccs.Field())
+	}
+	header := make([]byte, CcsHeaderSize)
+	copy(header, ccsMagic)
+	header[4] = ccsHeaderVersion
+	header[5] = byte(gnark.Version.Major)
+	header[6] = byte(gnark.Version.Minor)
+	header[7] = byte(gnark.Version.Patch)
+	binary.BigEndian.PutUint16(header[8:], uint16(curveID))
+	return header, nil
+}
+
+// curveOfField returns the pairing curve, among those groth16 supports, whose scalar field ccs is over.
+func curveOfField(ccs constraint.ConstraintSystem) (ecc.ID, bool) {
+	for _, id := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BLS24_317, ecc.BW6_761, ecc.BW6_633} {
+		if id.ScalarField().Cmp(ccs.Field()) == 0 {
+			return id, true
+		}
+	}
+	return ecc.UNKNOWN, false
+}
+
+// writeCcsHeader writes the header of ccs to w.
+func writeCcsHeader(w io.Writer, ccs constraint.ConstraintSystem) error {
+	header, err := ccsHeader(ccs)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(header)
+	return err
+}
+
+// readCcsFrom decodes a BN254 ccs from r, checking and skipping its header if there is one. gnark's format
+// only changes with its minor version, a header of another patch release is accepted.
+func readCcsFrom(r io.Reader) (constraint.ConstraintSystem, error) {
+	br := bufio.NewReader(r)
+	if magic, _ := br.Peek(len(ccsMagic)); bytes.Equal(magic, ccsMagic) {
+		header := make([]byte, CcsHeaderSize)
+		if _, err := io.ReadFull(br, header); err != nil {
+			return nil, fmt.Errorf("truncated ccs header: %w", io.ErrUnexpectedEOF)
+		}
+		if version := header[4]; version != ccsHeaderVersion {
+			return nil, fmt.Errorf("unsupported ccs format version %d", version)
+		}
+		if header[5] != byte(gnark.Version.Major) || header[6] != byte(gnark.Version.Minor) {
+			return nil, fmt.Errorf("ccs was written by gnark v%d.%d.%d, this build uses gnark v%s, whose format differs: re-export the ccs with this build",
+				header[5], header[6], header[7], gnark.Version)
+		}
+		if id := ecc.ID(binary.BigEndian.Uint16(header[8:])); id != ecc.BN254 {
+			return nil, fmt.Errorf("file holds a ccs on %s, expected %s", id, ecc.BN254)
+		}
+	}
+
+	ccs := groth16.NewCS(ecc.BN254)
+	if _, err := ccs.ReadFrom(br); err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
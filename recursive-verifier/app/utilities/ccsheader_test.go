@@ -0,0 +1,92 @@
+package utilities
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_CcsHeader(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "raw.ccs")
+	headed := filepath.Join(dir, "headed.ccs")
+	headedGzip := filepath.Join(dir, "headed.ccs.gz")
+
+	if err := WriteCcs(ccs, raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCcs(ccs, headed, WithCcsHeader()); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCcsGzip(ccs, headedGzip, WithCcsHeader()); err != nil {
+		t.Fatal(err)
+	}
+
+	rawData, err := os.ReadFile(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headedData, err := os.ReadFile(headed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, err := ccsHeader(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(headedData[:CcsHeaderSize], header) || !bytes.Equal(headedData[CcsHeaderSize:], rawData) {
+		t.Fatal("expected the header followed by the raw serialization")
+	}
+	if header[5] != byte(gnark.Version.Major) || header[6] != byte(gnark.Version.Minor) {
+		t.Fatalf("expected the gnark version %s in the header, got %v", gnark.Version, header[5:8])
+	}
+
+	// files with and without a header are read back alike
+	for _, fn := range []string{raw, headed, headedGzip} {
+		restored, err := ReadCcsAuto(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if restored.GetNbConstraints() != ccs.GetNbConstraints() {
+			t.Fatalf("%s: expected %d constraints, got %d", fn, ccs.GetNbConstraints(), restored.GetNbConstraints())
+		}
+	}
+	if _, err := ReadCcsCtx(context.Background(), headed); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		patch func(header []byte)
+		want  string
+	}{
+		{"other gnark", func(h []byte) { h[6]++ }, "written by gnark v"},
+		{"other curve", func(h []byte) { binary.BigEndian.PutUint16(h[8:], uint16(ecc.BLS12_381)) }, "on bls12_381"},
+		{"other format", func(h []byte) { h[4] = 2 }, "format version 2"},
+	} {
+		data := bytes.Clone(headedData)
+		tc.patch(data)
+		fn := filepath.Join(dir, "patched.ccs")
+		if err := os.WriteFile(fn, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ReadCcs(fn); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected an error mentioning %q, got %v", tc.name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package utilities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const checksumExt = ".sha256"
+
+// WriteWithChecksum writes fn through writeFn like WriteAtomic and then writes a <fn>.sha256 sidecar
+// holding the hex encoded SHA-256 digest of the written content.
+func WriteWithChecksum(fn string, writeFn func(io.Writer) error) error {
+	h := sha256.New()
+	err := WriteAtomic(fn, func(w io.Writer) error {
+		return writeFn(io.MultiWriter(w, h))
+	})
+	if err != nil {
+		return err
+	}
+
+	err = WriteAtomic(fn+checksumExt, func(w io.Writer) error {
+		_, err := io.WriteString(w, hex.EncodeToString(h.Sum(nil)))
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write checksum file for %s: %w", fn, err)
+	}
+
+	return nil
+}
+
+// VerifyChecksum recomputes the SHA-256 digest of fn and compares it with its <fn>.sha256 sidecar.
+func VerifyChecksum(fn string) (bool, error) {
+	expected, err := readFile(fn + checksumExt)
+	if err != nil {
+		return false, fmt.Errorf("failed to read checksum file for %s: %w", fn, err)
+	}
+
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return false, fmt.Errorf("failed to open %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return false, fmt.Errorf("failed to hash %s: %w", fn, err)
+	}
+
+	return strings.EqualFold(strings.TrimSpace(string(expected)), hex.EncodeToString(h.Sum(nil))), nil
+}
@@ -0,0 +1,38 @@
+package utilities
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_WriteWithChecksum(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "proof")
+	err := WriteWithChecksum(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, "proof bytes")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err := VerifyChecksum(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("expected checksum to match")
+	}
+
+	if err := os.WriteFile(fn, []byte("corrupted"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ok, err = VerifyChecksum(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected checksum mismatch after corruption")
+	}
+}
@@ -0,0 +1,199 @@
+package utilities
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"golang.org/x/crypto/sha3"
+)
+
+// VkEqual compares two BN254 verifying keys field by field. If they differ it returns false and a
+// description of the first difference, e.g. "G1.K[3] differs". Keys of another curve are never equal.
+func VkEqual(a, b groth16.VerifyingKey) (bool, string) {
+	_a, ok := a.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return false, fmt.Sprintf("unsupported verifying key type %T, only BN254 is supported", a)
+	}
+	_b, ok := b.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return false, fmt.Sprintf("unsupported verifying key type %T, only BN254 is supported", b)
+	}
+
+	g1 := []struct {
+		name string
+		a, b *bn254.G1Affine
+	}{
+		{"G1.Alpha", &_a.G1.Alpha, &_b.G1.Alpha},
+		{"G1.Beta", &_a.G1.Beta, &_b.G1.Beta},
+		{"G1.Delta", &_a.G1.Delta, &_b.G1.Delta},
+	}
+	for _, p := range g1 {
+		if !p.a.Equal(p.b) {
+			return false, p.name + " differs"
+		}
+	}
+
+	g2 := []struct {
+		name string
+		a, b *bn254.G2Affine
+	}{
+		{"G2.Beta", &_a.G2.Beta, &_b.G2.Beta},
+		{"G2.Gamma", &_a.G2.Gamma, &_b.G2.Gamma},
+		{"G2.Delta", &_a.G2.Delta, &_b.G2.Delta},
+	}
+	for _, p := range g2 {
+		if !p.a.Equal(p.b) {
+			return false, p.name + " differs"
+		}
+	}
+
+	if len(_a.G1.K) != len(_b.G1.K) {
+		return false, fmt.Sprintf("G1.K has %d points vs %d", len(_a.G1.K), len(_b.G1.K))
+	}
+	for i := range _a.G1.K {
+		if !_a.G1.K[i].Equal(&_b.G1.K[i]) {
+			return false, fmt.Sprintf("G1.K[%d] differs", i)
+		}
+	}
+
+	if len(_a.CommitmentKeys) != len(_b.CommitmentKeys) {
+		return false, fmt.Sprintf("CommitmentKeys has %d keys vs %d", len(_a.CommitmentKeys), len(_b.CommitmentKeys))
+	}
+	for i := range _a.CommitmentKeys {
+		if !_a.CommitmentKeys[i].G.Equal(&_b.CommitmentKeys[i].G) {
+			return false, fmt.Sprintf("CommitmentKeys[%d].G differs", i)
+		}
+		if !_a.CommitmentKeys[i].GSigmaNeg.Equal(&_b.CommitmentKeys[i].GSigmaNeg) {
+			return false, fmt.Sprintf("CommitmentKeys[%d].GSigmaNeg differs", i)
+		}
+	}
+
+	if len(_a.PublicAndCommitmentCommitted) != len(_b.PublicAndCommitmentCommitted) {
+		return false, fmt.Sprintf("PublicAndCommitmentCommitted has %d entries vs %d",
+			len(_a.PublicAndCommitmentCommitted), len(_b.PublicAndCommitmentCommitted))
+	}
+	for i := range _a.PublicAndCommitmentCommitted {
+		if !slices.Equal(_a.PublicAndCommitmentCommitted[i], _b.PublicAndCommitmentCommitted[i]) {
+			return false, fmt.Sprintf("PublicAndCommitmentCommitted[%d] differs", i)
+		}
+	}
+
+	return true, ""
+}
+
+// ProofEqual reports whether two BN254 proofs are exactly equal: the Ar, Bs and Krs points, the commitments,
+// in order, and the CommitmentPok. Points are compared as curve points, so a proof read back from either
+// point encoding equals the proof it was written from. Proofs of another curve are never equal. Groth16
+// proving is randomized: two proofs of the same witness only compare equal if the prover was seeded the same.
+func ProofEqual(a, b groth16.Proof) bool {
+	_a, ok := a.(*groth16_bn254.Proof)
+	if !ok {
+		return false
+	}
+	_b, ok := b.(*groth16_bn254.Proof)
+	if !ok {
+		return false
+	}
+	if !_a.Ar.Equal(&_b.Ar) || !_a.Bs.Equal(&_b.Bs) || !_a.Krs.Equal(&_b.Krs) {
+		return false
+	}
+	if len(_a.Commitments) != len(_b.Commitments) {
+		return false
+	}
+	for i := range _a.Commitments {
+		if !_a.Commitments[i].Equal(&_b.Commitments[i]) {
+			return false
+		}
+	}
+	return _a.CommitmentPok.Equal(&_b.CommitmentPok)
+}
+
+// VkElementsBN254 holds copies of the points of a BN254 verifying key, each in the uncompressed encoding of
+// gnark-crypto's Marshal: 64 bytes X || Y for G1 and 128 bytes X.A1 || X.A0 || Y.A1 || Y.A0 for G2, every
+// coordinate big-endian. Beta, Gamma and Delta are the key's own points, not the negations the Solidity
+// verifier embeds.
+type VkElementsBN254 struct {
+	Alpha []byte
+	Beta  []byte
+	Gamma []byte
+	Delta []byte
+	// IC holds the G1.K points, the constant term first followed by one point per public input.
+	IC [][]byte
+}
+
+// VkElements exposes the points of a BN254 verifying key as bytes, e.g. to compare gamma and delta against
+// the transcript of a trusted setup ceremony without depending on gnark's internal types.
+func VkElements(vk groth16.VerifyingKey) (VkElementsBN254, error) {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return VkElementsBN254{}, fmt.Errorf("unsupported verifying key type %T, only BN254 is supported", vk)
+	}
+
+	e := VkElementsBN254{
+		Alpha: _vk.G1.Alpha.Marshal(),
+		Beta:  _vk.G2.Beta.Marshal(),
+		Gamma: _vk.G2.Gamma.Marshal(),
+		Delta: _vk.G2.Delta.Marshal(),
+		IC:    make([][]byte, len(_vk.G1.K)),
+	}
+	for i := range _vk.G1.K {
+		e.IC[i] = _vk.G1.K[i].Marshal()
+	}
+	return e, nil
+}
+
+// VkHash returns the keccak256 digest identifying a BN254 verifying key, e.g. in an on-chain registry of
+// authorized keys. It hashes 32 byte big-endian words, so that Solidity computes the same digest with
+// keccak256(abi.encodePacked(...)) over uint256 values, in this order:
+//
+//	Alpha.X, Alpha.Y,
+//	Beta.X.A1, Beta.X.A0, Beta.Y.A1, Beta.Y.A0, then Gamma and Delta likewise,
+//	len(K), K[0].X, K[0].Y, K[1].X, ...,
+//	len(CommitmentKeys), for each commitment its key G and GSigmaNeg as G2 points, the number of public
+//	inputs it commits to and their indexes, counting the constant one wire as 0.
+//
+// G2 coordinates are in the EVM order of NormalizeG2ForEVM. Beta, Gamma and Delta are the key's own points,
+// not the negations the exported verifier embeds. The precomputed values of the key are not hashed, they
+// derive from these points.
+func VkHash(vk groth16.VerifyingKey) ([32]byte, error) {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return [32]byte{}, fmt.Errorf("unsupported verifying key type %T, only BN254 is supported", vk)
+	}
+	if len(_vk.CommitmentKeys) != len(_vk.PublicAndCommitmentCommitted) {
+		return [32]byte{}, fmt.Errorf("verifying key has %d commitment keys for %d commitments", len(_vk.CommitmentKeys), len(_vk.PublicAndCommitmentCommitted))
+	}
+
+	h := sha3.NewLegacyKeccak256()
+	word := func(n int) {
+		var b [32]byte
+		binary.BigEndian.PutUint64(b[24:], uint64(n))
+		h.Write(b[:])
+	}
+	// Marshal is the uncompressed encoding, already made of big-endian words in the EVM order
+	h.Write(_vk.G1.Alpha.Marshal())
+	h.Write(_vk.G2.Beta.Marshal())
+	h.Write(_vk.G2.Gamma.Marshal())
+	h.Write(_vk.G2.Delta.Marshal())
+	word(len(_vk.G1.K))
+	for i := range _vk.G1.K {
+		h.Write(_vk.G1.K[i].Marshal())
+	}
+	word(len(_vk.CommitmentKeys))
+	for i := range _vk.CommitmentKeys {
+		h.Write(_vk.CommitmentKeys[i].G.Marshal())
+		h.Write(_vk.CommitmentKeys[i].GSigmaNeg.Marshal())
+		word(len(_vk.PublicAndCommitmentCommitted[i]))
+		for _, idx := range _vk.PublicAndCommitmentCommitted[i] {
+			word(idx)
+		}
+	}
+
+	var digest [32]byte
+	h.Sum(digest[:0])
+	return digest, nil
+}
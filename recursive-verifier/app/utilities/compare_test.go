@@ -0,0 +1,206 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_VkEqual(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "vk")
+	if err := WriteVk(vk, fn); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadVk(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if equal, diff := VkEqual(vk, restored); !equal {
+		t.Fatalf("expected restored key to be equal, got %q", diff)
+	}
+
+	_, other, _ := genTestProof(t)
+	if equal, diff := VkEqual(vk, other); equal || diff != "G1.Alpha differs" {
+		t.Fatalf("expected keys of separate setups to differ at G1.Alpha, got %v %q", equal, diff)
+	}
+
+	modified := *restored.(*groth16_bn254.VerifyingKey)
+	modified.G1.K = append([]bn254.G1Affine(nil), modified.G1.K...)
+	modified.G1.K[1].Neg(&modified.G1.K[1])
+	if equal, diff := VkEqual(vk, &modified); equal || diff != "G1.K[1] differs" {
+		t.Fatalf("expected G1.K[1] to differ, got %v %q", equal, diff)
+	}
+}
+
+func Test_ProofEqual(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	dir := t.TempDir()
+
+	fn := filepath.Join(dir, "proof")
+	if err := WriteProof(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadProof(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ProofEqual(proof, restored) {
+		t.Fatal("expected the restored proof to be equal")
+	}
+	rawFn := filepath.Join(dir, "proof.raw")
+	if err := RecodeProof(fn, rawFn, false); err != nil {
+		t.Fatal(err)
+	}
+	if restored, err = ReadProof(rawFn); err != nil {
+		t.Fatal(err)
+	}
+	if !ProofEqual(proof, restored) {
+		t.Fatal("expected the proof read from uncompressed points to be equal")
+	}
+
+	other, _, _ := genTestProof(t)
+	if ProofEqual(proof, other) {
+		t.Fatal("expected proofs of separate setups to differ")
+	}
+	withCommitment := *proof.(*groth16_bn254.Proof)
+	withCommitment.Commitments = []bn254.G1Affine{withCommitment.Ar}
+	if ProofEqual(proof, &withCommitment) {
+		t.Fatal("expected an extra commitment to make proofs differ")
+	}
+	pok := *proof.(*groth16_bn254.Proof)
+	pok.CommitmentPok = pok.Krs
+	if ProofEqual(proof, &pok) {
+		t.Fatal("expected a different CommitmentPok to make proofs differ")
+	}
+
+	bls, _, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if ProofEqual(bls, bls) {
+		t.Fatal("expected BLS12-381 proofs to never be equal")
+	}
+}
+
+func Test_VkElements(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	_vk := vk.(*groth16_bn254.VerifyingKey)
+
+	e, err := VkElements(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(e.Alpha) != 64 || len(e.Delta) != 128 || len(e.IC) != len(_vk.G1.K) {
+		t.Fatalf("unexpected element sizes: alpha %d, delta %d, %d IC points", len(e.Alpha), len(e.Delta), len(e.IC))
+	}
+
+	// G2 points are laid out as the EVM takes them
+	for _, c := range []struct {
+		name string
+		got  []byte
+		p    *bn254.G2Affine
+	}{
+		{"Beta", e.Beta, &_vk.G2.Beta},
+		{"Gamma", e.Gamma, &_vk.G2.Gamma},
+		{"Delta", e.Delta, &_vk.G2.Delta},
+	} {
+		var want []byte
+		for _, w := range NormalizeG2ForEVM(c.p) {
+			want = append(want, w.FillBytes(make([]byte, 32))...)
+		}
+		if !bytes.Equal(c.got, want) {
+			t.Fatalf("%s: expected %x, got %x", c.name, want, c.got)
+		}
+	}
+	var k1 bn254.G1Affine
+	if err := k1.Unmarshal(e.IC[1]); err != nil || !k1.Equal(&_vk.G1.K[1]) {
+		t.Fatalf("expected IC[1] to decode to G1.K[1]: %v", err)
+	}
+
+	// the elements are copies
+	e.IC[0][0] ^= 0xff
+	if again, _ := VkElements(vk); bytes.Equal(again.IC[0], e.IC[0]) {
+		t.Fatal("expected modifying the elements to leave the key untouched")
+	}
+
+	_, bls, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if _, err := VkElements(bls); err == nil {
+		t.Fatal("expected a BLS12-381 key to be rejected")
+	}
+}
+
+func Test_VkHash(t *testing.T) {
+	vk, err := ReadVk(filepath.Join("testdata", "vk.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest, err := VkHash(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// pinned, a change means every key registered on chain has to be registered again
+	const want = "5d97b14f049b457b610685256fb711823ee5fcd7fcccba54ce2f51624488f81a"
+	if got := hex.EncodeToString(digest[:]); got != want {
+		t.Fatalf("expected hash %s, got %s", want, got)
+	}
+
+	// the same digest as abi.encodePacked over the uint256 words the documentation lists
+	_vk := vk.(*groth16_bn254.VerifyingKey)
+	var packed []byte
+	words := func(vs ...*big.Int) {
+		for _, v := range vs {
+			packed = append(packed, v.FillBytes(make([]byte, 32))...)
+		}
+	}
+	g1 := func(p *bn254.G1Affine) { words(p.X.BigInt(new(big.Int)), p.Y.BigInt(new(big.Int))) }
+	g2 := func(p *bn254.G2Affine) {
+		coords := NormalizeG2ForEVM(p)
+		words(coords[:]...)
+	}
+	g1(&_vk.G1.Alpha)
+	g2(&_vk.G2.Beta)
+	g2(&_vk.G2.Gamma)
+	g2(&_vk.G2.Delta)
+	words(big.NewInt(int64(len(_vk.G1.K))))
+	for i := range _vk.G1.K {
+		g1(&_vk.G1.K[i])
+	}
+	words(big.NewInt(0))
+	h := sha3.NewLegacyKeccak256()
+	h.Write(packed)
+	if !bytes.Equal(h.Sum(nil), digest[:]) {
+		t.Fatal("VkHash does not match keccak256 of the packed words")
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, committedVk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committedDigest, err := VkHash(committedVk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := *committedVk.(*groth16_bn254.VerifyingKey)
+	tampered.PublicAndCommitmentCommitted = [][]int{{}}
+	if tamperedDigest, err := VkHash(&tampered); err != nil || tamperedDigest == committedDigest {
+		t.Fatalf("expected the committed inputs to change the hash, got %v", err)
+	}
+
+	_, bls, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if _, err := VkHash(bls); err == nil {
+		t.Fatal("expected a BLS12-381 key to be rejected")
+	}
+}
@@ -0,0 +1,123 @@
+package utilities
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+)
+
+// ctxWriter fails every Write once ctx is done, so long serializations stop at the next chunk.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (cw *ctxWriter) Write(p []byte) (int, error) {
+	if err := cw.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cw.w.Write(p)
+}
+
+// ctxReader fails every Read once ctx is done, so long deserializations stop at the next chunk.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// WriteCcsCtx writes ccs like WriteCcs but aborts with ctx.Err() once ctx is done.
+// The partially written temporary file is removed and an existing fn is left untouched.
+func WriteCcsCtx(ctx context.Context, ccs constraint.ConstraintSystem, fn string, opts ...WriteOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		err := WriteCcsTo(&ctxWriter{ctx: ctx, w: w}, ccs, opts...)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}, append([]WriteOption{withKind("ccs")}, opts...)...)
+}
+
+// ReadCcsCtx reads a ccs like ReadCcs but aborts with ctx.Err() once ctx is done.
+func ReadCcsCtx(ctx context.Context, fn string) (constraint.ConstraintSystem, error) {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open ccs file %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	ccs, err := readCcsCtx(&ctxReader{ctx: ctx, r: f})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ccs file %s: %w", fn, err)
+	}
+
+	return ccs, nil
+}
+
+// readCcsCtx decodes a ccs from r, gzip compressed or not, like readCcsFS does.
+func readCcsCtx(r io.Reader) (constraint.ConstraintSystem, error) {
+	r, closeGzip, err := sniffGzip(r)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = closeGzip()
+	}()
+	return readCcsFrom(r)
+}
+
+// VerifyBatchCtx is VerifyBatch stopping once ctx is done: pairs not verified by then get ctx.Err().
+func VerifyBatchCtx(ctx context.Context, vk groth16.VerifyingKey, pairs []ProofWitnessPair, parallelism int) []error {
+	if parallelism <= 0 {
+		parallelism = runtime.GOMAXPROCS(0)
+	}
+	parallelism = min(parallelism, len(pairs))
+
+	errs := make([]error, len(pairs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(parallelism)
+	for w := 0; w < parallelism; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				errs[i] = VerifyLoadedProof(pairs[i].Proof, vk, pairs[i].PublicWitness)
+			}
+		}()
+	}
+
+	for i := range pairs {
+		// checked first as select picks randomly among ready cases
+		if ctx.Err() == nil {
+			select {
+			case next <- i:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		for j := i; j < len(pairs); j++ {
+			errs[j] = ctx.Err()
+		}
+		break
+	}
+	close(next)
+	wg.Wait()
+
+	return errs
+}
@@ -0,0 +1,74 @@
+package utilities
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_CcsCtx(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "circuit.ccs")
+
+	observer := &recordingObserver{}
+	if err := WriteCcsCtx(context.Background(), ccs, fn, WithObserver(observer)); err != nil {
+		t.Fatal(err)
+	}
+	if len(observer.kinds) != 1 || observer.kinds[0] != "ccs" {
+		t.Fatalf("expected a ccs write to be observed, got %v", observer.kinds)
+	}
+	if _, err := ReadCcsCtx(context.Background(), fn); err != nil {
+		t.Fatal(err)
+	}
+	gzipFn := filepath.Join(t.TempDir(), "circuit.ccs.gz")
+	if err := WriteCcsGzip(ccs, gzipFn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCcsCtx(context.Background(), gzipFn); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := ReadCcsCtx(ctx, fn); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	cancelledFn := filepath.Join(dir, "cancelled.ccs")
+	if err := WriteCcsCtx(ctx, ccs, cancelledFn); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected the partial file to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func Test_VerifyBatchCtx_Canceled(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	pairs := []ProofWitnessPair{{proof, publicWitness}, {proof, publicWitness}, {proof, publicWitness}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for i, err := range VerifyBatchCtx(ctx, vk, pairs, 2) {
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("pair %d: expected context.Canceled, got %v", i, err)
+		}
+	}
+}
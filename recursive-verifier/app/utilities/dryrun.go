@@ -0,0 +1,74 @@
+package utilities
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// DryRun, when non-nil, makes OpenFileOnCreateOrOverwrite, WriteAtomic and every Write* helper built on them
+// run their serialization against a byte counter and record the result in the recorder instead of creating
+// any file or directory. A file opened with OpenFileOnCreateOrOverwrite is recorded when it is closed.
+var DryRun *DryRunRecorder
+
+// DryRunEntry describes a file a Write* helper would have written.
+type DryRunEntry struct {
+	Path string
+	Size int64
+	Mode os.FileMode
+}
+
+// DryRunRecorder collects the files that would have been written while DryRun is set.
+// It is safe for concurrent use.
+type DryRunRecorder struct {
+	mu      sync.Mutex
+	entries []DryRunEntry
+}
+
+// Manifest returns the recorded entries in the order the writes completed.
+func (r *DryRunRecorder) Manifest() []DryRunEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]DryRunEntry(nil), r.entries...)
+}
+
+// record runs writeFn against a counter and, if it succeeds, adds fn to the manifest.
+func (r *DryRunRecorder) record(fn string, mode os.FileMode, writeFn func(w io.Writer) error) error {
+	var cw countingWriter
+	if err := writeFn(&cw); err != nil {
+		return err
+	}
+	r.add(fn, cw.n, mode)
+	return nil
+}
+
+func (r *DryRunRecorder) add(fn string, size int64, mode os.FileMode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = append(r.entries, DryRunEntry{Path: fn, Size: size, Mode: mode})
+}
+
+// dryRunFile is the WritableFile of an AtomicFile opened in dry-run mode, counting what is written to it.
+type dryRunFile struct {
+	countingWriter
+}
+
+func (*dryRunFile) Sync() error  { return nil }
+func (*dryRunFile) Close() error { return nil }
+
+// countingWriter counts the bytes written through it to w, or discards them if w is nil.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.w == nil {
+		cw.n += int64(len(p))
+		return len(p), nil
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
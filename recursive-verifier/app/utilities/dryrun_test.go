@@ -0,0 +1,76 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func Test_DryRun(t *testing.T) {
+	proof, vk, _ := genTestProof(t)
+
+	recorder := &DryRunRecorder{}
+	DryRun = recorder
+	defer func() { DryRun = nil }()
+
+	dir := filepath.Join(t.TempDir(), "out")
+	proofFn := filepath.Join(dir, "proof")
+	vkFn := filepath.Join(dir, "vk")
+	if err := WriteProof(proof, proofFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVk(vk, vkFn); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected dry run not to create %s, got %v", dir, err)
+	}
+
+	manifest := recorder.Manifest()
+	if len(manifest) != 2 || manifest[0].Path != proofFn || manifest[1].Path != vkFn {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	proofBytes, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if manifest[0].Size != int64(len(proofBytes)) || manifest[0].Mode != DefaultFileMode {
+		t.Fatalf("unexpected proof entry %+v, expected %d bytes", manifest[0], len(proofBytes))
+	}
+}
+
+func Test_DryRun_OpenFileOnCreateOrOverwrite(t *testing.T) {
+	recorder := &DryRunRecorder{}
+	DryRun = recorder
+	defer func() { DryRun = nil }()
+
+	fn := filepath.Join(t.TempDir(), "out", "data")
+	f, err := OpenFileOnCreateOrOverwriteWithMode(fn, SensitiveFileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Dir(fn)); !os.IsNotExist(err) {
+		t.Fatalf("expected dry run not to create %s, got %v", filepath.Dir(fn), err)
+	}
+
+	discarded, err := OpenFileOnCreateOrOverwrite(fn + ".discarded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := discarded.Discard(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []DryRunEntry{{Path: fn, Size: 4, Mode: SensitiveFileMode}}
+	if got := recorder.Manifest(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected manifest %+v, got %+v", want, got)
+	}
+}
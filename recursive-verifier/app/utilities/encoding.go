@@ -0,0 +1,263 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// Encoding is the text encoding of a proof file written by WriteProofEncoded.
+type Encoding int
+
+const (
+	// EncodingRaw is the binary serialization written by WriteProof.
+	EncodingRaw Encoding = iota
+	// EncodingHex is the binary serialization as lowercase hex, read back with or without a 0x prefix.
+	EncodingHex
+	// EncodingBase64 is the binary serialization in standard, padded base64.
+	EncodingBase64
+	// EncodingAuto only applies to ReadProofEncoded, which then detects the encoding of the file.
+	EncodingAuto
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingRaw:
+		return "raw"
+	case EncodingHex:
+		return "hex"
+	case EncodingBase64:
+		return "base64"
+	case EncodingAuto:
+		return "auto"
+	}
+	return fmt.Sprintf("Encoding(%d)", int(e))
+}
+
+// WriteProofEncoded writes the binary serialization of proof, as WriteProof does, encoded as enc.
+// The header of ProofFormatV1 is part of the encoded bytes.
+func WriteProofEncoded(proof groth16.Proof, fn string, enc Encoding, opts ...WriteOption) error {
+	if enc == EncodingRaw {
+		return WriteProof(proof, fn, opts...)
+	}
+
+	data, err := MarshalProof(proof)
+	if err != nil {
+		return err
+	}
+	if ProofFileFormat == ProofFormatV1 {
+		data = append(proofHeader(proof.CurveID()), data...)
+	}
+	var text []byte
+	switch enc {
+	case EncodingHex:
+		text = []byte(hex.EncodeToString(data))
+	case EncodingBase64:
+		text = []byte(base64.StdEncoding.EncodeToString(data))
+	default:
+		return fmt.Errorf("unsupported proof encoding %s", enc)
+	}
+
+	opts = append([]WriteOption{withKind("proof")}, opts...)
+	err = WriteAtomic(fn, func(w io.Writer) error {
+		_, err := w.Write(text)
+		return err
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	if !newWriteConfig(opts).verify || DryRun != nil {
+		return nil
+	}
+	return verifyWrittenProofText(fn, enc, proof.CurveID(), data)
+}
+
+// verifyWrittenProofText is verifyWrittenProof for a proof file in the text encoding enc.
+func verifyWrittenProofText(fn string, enc Encoding, curveID ecc.ID, want []byte) error {
+	text, err := readFile(fn)
+	if err != nil {
+		return fmt.Errorf("failed to read back proof file %s: %w", fn, err)
+	}
+	written, err := decodeProofText(bytes.TrimSpace(text), enc)
+	if err != nil {
+		return fmt.Errorf("proof file %s is corrupted: %w", fn, err)
+	}
+	// want excludes the header, which checkWrittenProof strips
+	return checkWrittenProof(fn, written, curveID, want)
+}
+
+// ReadProofEncoded restores a BN254 proof written by WriteProofEncoded with enc, or by WriteProof for EncodingRaw.
+// Surrounding whitespace of text encodings is ignored. EncodingAuto treats a file as text if every byte is a
+// hex or base64 character or whitespace, as hex if every character is a hex digit and as base64 if not, and
+// as raw otherwise. The coordinates of a raw proof, compressed or not, are practically never all printable.
+func ReadProofEncoded(fn string, enc Encoding) (groth16.Proof, error) {
+	data, err := readFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+
+	if enc == EncodingAuto {
+		enc = detectEncoding(data)
+	}
+	if enc != EncodingRaw {
+		data, err = decodeProofText(bytes.TrimSpace(data), enc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s proof file %s: %w", enc, fn, err)
+		}
+	}
+
+	data, err = stripProofHeader(data, ecc.BN254)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	proof, err := unmarshalProof(data, ecc.BN254)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	return proof, nil
+}
+
+// WriteProofCompressed writes proof with every point in compressed form, half the size of gnark's raw
+// encoding. This is the encoding WriteProof already writes, gnark's WriteTo compresses points, the
+// function states it for callers that rely on the size, e.g. to transport proofs over mobile networks.
+func WriteProofCompressed(proof groth16.Proof, fn string, opts ...WriteOption) error {
+	return WriteProof(proof, fn, opts...)
+}
+
+// ReadProofCompressed restores a BN254 proof written by WriteProofCompressed. Unlike ReadProof, which also
+// accepts the uncompressed points of gnark's WriteRawTo, it rejects a proof that is not entirely compressed.
+// Every point is decompressed and checked to be on the curve and in the prime order subgroup.
+func ReadProofCompressed(fn string) (groth16.Proof, error) {
+	data, err := readFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	data, err = stripProofHeader(data, ecc.BN254)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	proof, err := unmarshalProof(data, ecc.BN254)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	// the decoder picks the encoding of each point from its flag bits, only the size tells them all apart
+	if want := compressedProofSize(len(proof.(*groth16_bn254.Proof).Commitments)); len(data) != want {
+		return nil, fmt.Errorf("proof file %s is not compressed: %d bytes, %d compressed", fn, len(data), want)
+	}
+	return proof, nil
+}
+
+// compressedProofSize is the size of a compressed BN254 proof: Ar, Bs, Krs, the commitment count, the
+// commitments and their proof of knowledge.
+func compressedProofSize(nbCommitments int) int {
+	return 2*bn254.SizeOfG1AffineCompressed + bn254.SizeOfG2AffineCompressed + 4 +
+		(nbCommitments+1)*bn254.SizeOfG1AffineCompressed
+}
+
+// uncompressedProofSize is the size of a BN254 proof written by WriteRawTo, as compressedProofSize.
+func uncompressedProofSize(nbCommitments int) int {
+	return 2*bn254.SizeOfG1AffineUncompressed + bn254.SizeOfG2AffineUncompressed + 4 +
+		(nbCommitments+1)*bn254.SizeOfG1AffineUncompressed
+}
+
+// RecodeProof rewrites the BN254 proof in inFn to outFn with every point compressed, as WriteProof writes
+// it, or uncompressed, as gnark's WriteRawTo writes it, e.g. to normalize an archive holding both. The input
+// may be in either encoding, or mix them, and may be gzip compressed or carry the ProofFormatV1 header; the
+// output carries the header as ProofFileFormat says. The recoded proof is decoded again and compared with
+// the input before anything is written, so a proof that does not survive the round trip leaves outFn
+// untouched. inFn and outFn may be the same file.
+func RecodeProof(inFn, outFn string, toCompressed bool) error {
+	proof, err := ReadProof(inFn)
+	if err != nil {
+		return err
+	}
+	_proof := proof.(*groth16_bn254.Proof)
+
+	var buf bytes.Buffer
+	want := uncompressedProofSize(len(_proof.Commitments))
+	if toCompressed {
+		_, err = _proof.WriteTo(&buf)
+		want = compressedProofSize(len(_proof.Commitments))
+	} else {
+		_, err = _proof.WriteRawTo(&buf)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode proof of %s: %w", inFn, err)
+	}
+	data := buf.Bytes()
+	if len(data) != want {
+		return fmt.Errorf("encoded proof of %s is %d bytes, expected %d", inFn, len(data), want)
+	}
+
+	recoded, err := unmarshalProof(data, ecc.BN254)
+	if err != nil {
+		return fmt.Errorf("recoded proof of %s does not decode: %w", inFn, err)
+	}
+	if !ProofEqual(proof, recoded) {
+		return fmt.Errorf("recoded proof of %s decodes to a different proof", inFn)
+	}
+
+	return WriteAtomic(outFn, func(w io.Writer) error {
+		if ProofFileFormat == ProofFormatV1 {
+			if _, err := w.Write(proofHeader(ecc.BN254)); err != nil {
+				return err
+			}
+		}
+		_, err := w.Write(data)
+		return err
+	}, withKind("proof"))
+}
+
+func decodeProofText(text []byte, enc Encoding) ([]byte, error) {
+	switch enc {
+	case EncodingHex:
+		text = bytes.TrimPrefix(bytes.TrimPrefix(text, []byte("0x")), []byte("0X"))
+		data := make([]byte, hex.DecodedLen(len(text)))
+		if _, err := hex.Decode(data, text); err != nil {
+			return nil, err
+		}
+		return data, nil
+	case EncodingBase64:
+		data := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+		n, err := base64.StdEncoding.Decode(data, text)
+		if err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	}
+	return nil, fmt.Errorf("unsupported proof encoding %s", enc)
+}
+
+func detectEncoding(data []byte) Encoding {
+	if len(data) == 0 || hasProofHeader(data) {
+		return EncodingRaw
+	}
+	for _, c := range data {
+		if !isBase64Char(c) && c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			return EncodingRaw
+		}
+	}
+
+	text := bytes.TrimSpace(data)
+	text = bytes.TrimPrefix(bytes.TrimPrefix(text, []byte("0x")), []byte("0X"))
+	for _, c := range text {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return EncodingBase64
+		}
+	}
+	return EncodingHex
+}
+
+// isBase64Char reports whether c belongs to the standard base64 alphabet or is its padding, hex digits and
+// the x of a 0x prefix included.
+func isBase64Char(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '+' || c == '/' || c == '='
+}
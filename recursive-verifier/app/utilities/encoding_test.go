@@ -0,0 +1,217 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+func Test_ProofEncodings(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+
+	for _, enc := range []Encoding{EncodingRaw, EncodingHex, EncodingBase64} {
+		fn := filepath.Join(dir, "proof."+enc.String())
+		if err := WriteProofEncoded(proof, fn, enc); err != nil {
+			t.Fatal(err)
+		}
+		for _, readEnc := range []Encoding{enc, EncodingAuto} {
+			p, err := ReadProofEncoded(fn, readEnc)
+			if err != nil {
+				t.Fatalf("%s read as %s: %v", enc, readEnc, err)
+			}
+			if err := groth16.Verify(p, vk, publicWitness); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// text produced by other tools, 0x prefixed and newline terminated
+	data, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, "proof.txt")
+	if err := os.WriteFile(fn, []byte("0x"+hex.EncodeToString(data)+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofEncoded(fn, EncodingAuto); err != nil {
+		t.Fatal(err)
+	}
+
+	// uncompressed points start with the flag 0b00, their first byte is usually printable
+	rawFn := filepath.Join(dir, "proof.uncompressed")
+	if err := RecodeProof(filepath.Join(dir, "proof."+EncodingRaw.String()), rawFn, false); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ReadProofEncoded(rawFn, EncodingAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(p, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadProofEncoded(filepath.Join(dir, "proof.hex"), EncodingBase64); err == nil {
+		t.Fatal("expected hex decoded as base64 to fail")
+	}
+}
+
+func Test_WriteProofEncoded_VerifyAfterWrite(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	dir := t.TempDir()
+
+	for _, enc := range []Encoding{EncodingHex, EncodingBase64} {
+		fn := filepath.Join(dir, "proof."+enc.String())
+		if err := WriteProofEncoded(proof, fn, enc, WithVerifyAfterWrite()); err != nil {
+			t.Fatalf("%s: %v", enc, err)
+		}
+		DefaultFilesystem = corruptingFilesystem{Filesystem: OSFilesystem{}, offset: 80}
+		err := WriteProofEncoded(proof, fn, enc, WithVerifyAfterWrite())
+		DefaultFilesystem = OSFilesystem{}
+		if err == nil || !strings.Contains(err.Error(), "corrupted") {
+			t.Fatalf("%s: expected verification to detect the corruption, got %v", enc, err)
+		}
+	}
+}
+
+func Test_ProofCompressed(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+
+	fn := filepath.Join(dir, "proof")
+	if err := WriteProofCompressed(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != compressedProofSize(0) {
+		t.Fatalf("expected %d bytes, got %d", compressedProofSize(0), len(data))
+	}
+	restored, err := ReadProofCompressed(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(restored, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	var raw bytes.Buffer
+	if _, err := proof.(*groth16_bn254.Proof).WriteRawTo(&raw); err != nil {
+		t.Fatal(err)
+	}
+	rawFn := filepath.Join(dir, "proof.raw")
+	if err := os.WriteFile(rawFn, raw.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProof(rawFn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofCompressed(rawFn); err == nil {
+		t.Fatal("expected an uncompressed proof to be rejected")
+	}
+
+	// a changed Bs is off the curve or, with overwhelming probability, outside the G2 subgroup; any x
+	// coordinate on the curve is a valid G1 point, so corrupting Ar could go unnoticed
+	data[bn254.SizeOfG1AffineCompressed+bn254.SizeOfG2AffineCompressed-1] ^= 1
+	if err := os.WriteFile(fn, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofCompressed(fn); err == nil {
+		t.Fatal("expected a corrupted point to be rejected")
+	}
+}
+
+func benchmarkReadProofEncoding(b *testing.B, write func(*groth16_bn254.Proof, io.Writer) (int64, error)) {
+	proof, _, _ := genTestProof(b)
+	var buf bytes.Buffer
+	if _, err := write(proof.(*groth16_bn254.Proof), &buf); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := unmarshalProof(buf.Bytes(), ecc.BN254); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(buf.Len()), "proof-bytes")
+}
+
+// BenchmarkReadProofCompressed and BenchmarkReadProofRaw compare the size of both encodings and the decoding
+// cost, decompressing a point takes a square root that the raw encoding avoids.
+func BenchmarkReadProofCompressed(b *testing.B) {
+	benchmarkReadProofEncoding(b, (*groth16_bn254.Proof).WriteTo)
+}
+
+func BenchmarkReadProofRaw(b *testing.B) {
+	benchmarkReadProofEncoding(b, (*groth16_bn254.Proof).WriteRawTo)
+}
+
+func Test_RecodeProof(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+
+	compressedFn := filepath.Join(dir, "proof")
+	if err := WriteProof(proof, compressedFn); err != nil {
+		t.Fatal(err)
+	}
+	rawFn := filepath.Join(dir, "proof.raw")
+	if err := RecodeProof(compressedFn, rawFn, false); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(rawFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(raw) != uncompressedProofSize(0) {
+		t.Fatalf("expected %d bytes, got %d", uncompressedProofSize(0), len(raw))
+	}
+	if _, err := ReadProofCompressed(rawFn); err == nil {
+		t.Fatal("expected the recoded proof to be uncompressed")
+	}
+	restored, err := ReadProof(rawFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(restored, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	// back in place, byte for byte the proof WriteProof wrote
+	if err := RecodeProof(rawFn, rawFn, true); err != nil {
+		t.Fatal(err)
+	}
+	back, err := os.ReadFile(rawFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := os.ReadFile(compressedFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(back, original) {
+		t.Fatal("round trip through the uncompressed encoding changed the proof file")
+	}
+
+	outFn := filepath.Join(dir, "out")
+	if err := os.WriteFile(filepath.Join(dir, "garbage"), []byte("not a proof"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := RecodeProof(filepath.Join(dir, "garbage"), outFn, true); err == nil {
+		t.Fatal("expected an invalid proof to be rejected")
+	}
+	if _, err := os.Stat(outFn); !os.IsNotExist(err) {
+		t.Fatal("expected no output for an invalid proof")
+	}
+}
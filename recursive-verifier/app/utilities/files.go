@@ -1,227 +1,2268 @@
 package utilities
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
+	"io/fs"
 	"math/big"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/groth16"
+	groth16_bls12381 "github.com/consensys/gnark/backend/groth16/bls12-381"
 	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend/schema"
+	"golang.org/x/crypto/sha3"
 )
 
+var (
+	// DefaultDirMode is the permission used for directories created by CheckOrCreateDir.
+	DefaultDirMode os.FileMode = 0o755
+	// DefaultFileMode is the permission of files created by OpenFileOnCreateOrOverwrite and the Write* helpers.
+	DefaultFileMode os.FileMode = 0o644
+)
+
+// SensitiveFileMode is used for artifacts holding setup material, such as proving keys.
+const SensitiveFileMode os.FileMode = 0o600
+
+// ErrStat is returned, wrapping the underlying error, when a path cannot be stat'ed for a reason
+// other than it not existing. Use errors.Is(err, os.ErrPermission) and friends to branch on the cause.
+var ErrStat = errors.New("stat error")
+
+// ErrFileTooLarge is returned by the size limited readers when a file exceeds the allowed number of bytes.
+var ErrFileTooLarge = errors.New("file too large")
+
 // FileExists checks if a file exists at the given path.
 func FileExists(path string) (bool, error) {
-	_, err := os.Stat(path)
+	_, err := DefaultFilesystem.Stat(path)
 	if err == nil {
 		return true, nil
 	}
 	if os.IsNotExist(err) {
 		return false, nil
 	}
-	return false, fmt.Errorf("stat error: %v", err)
+	return false, fmt.Errorf("%w: %w", ErrStat, err)
 }
 
 // CheckOrCreateDir checks if the directory of file exists, and creates it if it does not exist.
+// It is safe to call concurrently for the same directory, losing a creation race is not an error.
 func CheckOrCreateDir(file string) error {
 	dir := filepath.Dir(file)
 
-	_, err := os.Stat(dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return err
+	// only pay for the extra Stat when someone listens
+	var existed bool
+	if Logger != nil {
+		_, statErr := DefaultFilesystem.Stat(dir)
+		existed = statErr == nil
+	}
+	err := DefaultFilesystem.MkdirAll(dir, DefaultDirMode)
+	if err == nil {
+		if !existed {
+			logDebug("created directory", "dir", dir)
 		}
-
-		err = os.MkdirAll(dir, os.ModePerm)
-		if err != nil {
-			return err
+		return nil
+	}
+	if errors.Is(err, fs.ErrExist) {
+		if info, statErr := DefaultFilesystem.Stat(dir); statErr == nil && info.IsDir() {
+			return nil
 		}
 	}
 
-	return nil
+	return fmt.Errorf("failed to create directory %s: %w", dir, err)
 }
 
 // OpenFileOnCreaterOverwrite opens a file, creating any missing directories, and overwriting the file if it already exists.
-// It returns an os.File pointer that should be closed by the caller.
-func OpenFileOnCreateOrOverwrite(file string) (*os.File, error) {
-	exists, err := FileExists(file)
+// It returns an AtomicFile that should be closed by the caller. Writes go to a temporary file in the same directory
+// which only replaces the original on a successful Close, so a failed write never destroys an existing artifact.
+//
+// It used to return the *os.File of the target itself. The AtomicFile it returns now is not an *os.File and
+// does not expose its methods: Name reports the target, which only exists after Close, and TempName the file
+// being written, so code that called Stat or Chmod on the handle must use TempName before Close or the target
+// after it.
+func OpenFileOnCreateOrOverwrite(file string) (*AtomicFile, error) {
+	return OpenFileOnCreateOrOverwriteWithMode(file, DefaultFileMode)
+}
+
+// OpenFileOnCreateOrOverwriteWithMode is OpenFileOnCreateOrOverwrite creating the file with the given permissions.
+func OpenFileOnCreateOrOverwriteWithMode(file string, mode os.FileMode) (*AtomicFile, error) {
+	file, err := checkBaseDir(file)
 	if err != nil {
 		return nil, err
 	}
+	if DryRun != nil {
+		// nothing is created, Close records what would have been written instead
+		return &AtomicFile{File: &dryRunFile{}, target: file, mode: mode, dryRun: DryRun}, nil
+	}
+
+	if err := CheckOrCreateDir(file); err != nil {
+		return nil, err
+	}
+
+	tmpFile, tmpName, err := createTargetTemp(DefaultFilesystem, file, mode)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file for %s: %w", file, err)
+	}
+
+	f := &AtomicFile{File: tmpFile, fsys: DefaultFilesystem, tmpName: tmpName, target: file, sensitive: isSensitiveMode(mode)}
+	if Logger != nil {
+		_, statErr := DefaultFilesystem.Stat(file)
+		f.replaces = statErr == nil
+	}
+	registerTemp(f)
+	return f, nil
+}
+
+// AtomicFile is a temporary file that is renamed over its target on Close.
+// If any write failed, Close discards the temporary file instead and returns the write error.
+// Until then the temporary file is known to CleanupTemps.
+type AtomicFile struct {
+	File      WritableFile
+	fsys      Filesystem
+	tmpName   string
+	target    string
+	sensitive bool
+	replaces  bool
+	writeErr  error
+	done      bool
+	mode      os.FileMode
+	dryRun    *DryRunRecorder
+}
+
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.recordErr(err)
+	return n, err
+}
+
+func (f *AtomicFile) WriteString(s string) (int, error) {
+	n, err := io.WriteString(f.File, s)
+	f.recordErr(err)
+	return n, err
+}
+
+func (f *AtomicFile) ReadFrom(r io.Reader) (int64, error) {
+	n, err := io.Copy(f.File, r)
+	f.recordErr(err)
+	return n, err
+}
+
+func (f *AtomicFile) recordErr(err error) {
+	if err != nil && f.writeErr == nil {
+		f.writeErr = err
+	}
+}
+
+// Name returns the path the file will be renamed to on Close.
+func (f *AtomicFile) Name() string {
+	return f.target
+}
+
+// TempName returns the path of the temporary file being written, which Close renames to Name. It is empty in
+// dry-run mode, where nothing is written.
+func (f *AtomicFile) TempName() string {
+	return f.tmpName
+}
+
+// Close syncs the temporary file and renames it over the target.
+func (f *AtomicFile) Close() error {
+	if f.done {
+		return nil
+	}
+	if f.writeErr != nil {
+		_ = f.Discard()
+		return fmt.Errorf("not replacing %s after a failed write: %w", f.target, f.writeErr)
+	}
+	f.done = true
+	if f.dryRun != nil {
+		f.dryRun.add(f.target, f.File.(*dryRunFile).n, f.mode)
+		return nil
+	}
+
+	if err := f.File.Sync(); err != nil {
+		_ = f.File.Close()
+		_ = removeTemp(f)
+		return fmt.Errorf("failed to sync temporary file for %s: %w", f.target, err)
+	}
+	if err := f.File.Close(); err != nil {
+		_ = removeTemp(f)
+		return fmt.Errorf("failed to close temporary file for %s: %w", f.target, err)
+	}
+	if err := f.fsys.Rename(f.tmpName, f.target); err != nil {
+		_ = removeTemp(f)
+		return fmt.Errorf("failed to rename temporary file to %s: %w", f.target, err)
+	}
+	unregisterTemp(f)
+	logDebug("wrote file", "file", f.target, "replaced", f.replaces)
+
+	return nil
+}
+
+// Discard removes the temporary file and leaves the target untouched.
+func (f *AtomicFile) Discard() error {
+	if f.done {
+		return nil
+	}
+	f.done = true
+	if f.dryRun != nil {
+		return nil
+	}
+
+	_ = f.File.Close()
+	return removeTemp(f)
+}
+
+// WriteAtomic writes a file by passing a temporary file in the same directory to writeFn and renaming
+// it over fn once writeFn and Close succeed. A failed or interrupted write never leaves a truncated fn behind.
+func WriteAtomic(fn string, writeFn func(io.Writer) error, opts ...WriteOption) error {
+	return WriteAtomicWithMode(fn, DefaultFileMode, writeFn, opts...)
+}
 
-	if exists {
-		err := os.Remove(file)
+// WriteAtomicWithMode is WriteAtomic creating fn with the given permissions.
+func WriteAtomicWithMode(fn string, mode os.FileMode, writeFn func(io.Writer) error, opts ...WriteOption) error {
+	if DryRun != nil {
+		fn, err := checkBaseDir(fn)
 		if err != nil {
-			return nil, err
+			return err
 		}
-	} else {
-		err := CheckOrCreateDir(file)
+		return DryRun.record(fn, mode, writeFn)
+	}
+
+	cfg := newWriteConfig(opts)
+	return withRetry(cfg, func() error {
+		start := time.Now()
+		f, err := OpenFileOnCreateOrOverwriteWithMode(fn, mode)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		var w io.Writer = f
+		if cfg.progress != nil {
+			w = &progressWriter{w: w, fn: cfg.progress}
+		}
+		var cw *countingWriter
+		if cfg.observer != nil {
+			cw = &countingWriter{w: w}
+			w = cw
+		}
+
+		if err := writeFn(w); err != nil {
+			_ = f.Discard()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return err
+		}
+
+		if cfg.observer != nil {
+			cfg.observer.OnWrite(cfg.kind, cw.n, time.Since(start))
+		}
+		return nil
+	})
+}
+
+func WriteCcs(ccs constraint.ConstraintSystem, fn string, opts ...WriteOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteCcsTo(w, ccs, opts...)
+	}, append([]WriteOption{withKind("ccs")}, opts...)...)
+}
+
+// WriteCcsTo streams the binary serialization of ccs to w, prefixed with a header with WithCcsHeader. The
+// other options do not apply to a stream and are ignored.
+func WriteCcsTo(w io.Writer, ccs constraint.ConstraintSystem, opts ...WriteOption) error {
+	if newWriteConfig(opts).ccsHeader {
+		if err := writeCcsHeader(w, ccs); err != nil {
+			return err
 		}
 	}
+	_, err := ccs.WriteTo(w)
+	return err
+}
 
-	fFile, err := os.Create(file)
+// ReadCcs restores a BN254 R1CS constraint system written by WriteCcs or WriteCcsGzip, whatever the
+// extension of fn: a gzip stream is detected by its magic bytes and decompressed.
+func ReadCcs(fn string, opts ...ReadOption) (constraint.ConstraintSystem, error) {
+	ccs, err := readCcsFS(pathFS(), fn, 0, opts...)
 	if err != nil {
 		return nil, err
 	}
-	return fFile, nil
+	logDebug("read ccs", "file", fn, "constraints", ccs.GetNbConstraints())
+	return ccs, nil
+}
+
+// ReadCcsLimited is ReadCcs refusing to decode files larger than maxBytes, returning ErrFileTooLarge instead.
+// The size is checked before decoding so an untrusted file cannot make ReadFrom allocate from its length headers.
+// A compressed file is also decoded from at most maxBytes of decompressed data.
+func ReadCcsLimited(fn string, maxBytes int64) (constraint.ConstraintSystem, error) {
+	return readCcsFS(pathFS(), fn, maxBytes)
+}
+
+// ReadCcsFS restores a BN254 R1CS constraint system named name from fsys, e.g. an embed.FS or fstest.MapFS.
+func ReadCcsFS(fsys fs.FS, name string) (constraint.ConstraintSystem, error) {
+	return readCcsFS(fsys, name, 0)
 }
 
-func WriteCcs(ccs constraint.ConstraintSystem, fn string) error {
-	openFile, err := OpenFileOnCreateOrOverwrite(fn)
+// readCcsFS reads a ccs from fsys, a maxBytes <= 0 disables the size check.
+func readCcsFS(fsys fs.FS, fn string, maxBytes int64, opts ...ReadOption) (constraint.ConstraintSystem, error) {
+	f, err := fsys.Open(fn)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to open ccs file %s: %w", fn, err)
 	}
 	defer func() {
-		_ = openFile.Close()
+		_ = f.Close()
 	}()
 
-	_, err = ccs.WriteTo(openFile)
+	r, err := newReadConfig(opts).wrapReader(f)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to stat ccs file %s: %w", fn, err)
 	}
-
-	return nil
-}
-
-func WriteVkInSolidity(vk groth16.VerifyingKey, fn string) error {
-	openFile, err := OpenFileOnCreateOrOverwrite(fn)
+	if maxBytes > 0 {
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat ccs file %s: %w", fn, err)
+		}
+		if info.Size() > maxBytes {
+			return nil, fmt.Errorf("%w: ccs file %s is %d bytes, limit is %d", ErrFileTooLarge, fn, info.Size(), maxBytes)
+		}
+		// The file may grow between Stat and ReadFrom, never read past the limit.
+		r = io.LimitReader(r, maxBytes)
+	}
+	r, closeGzip, err := sniffGzip(r)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to read ccs file %s: %w", fn, err)
 	}
 	defer func() {
-		_ = openFile.Close()
+		_ = closeGzip()
 	}()
+	if maxBytes > 0 {
+		r = io.LimitReader(r, maxBytes)
+	}
 
-	err = vk.ExportSolidity(openFile)
+	ccs, err := readCcsFrom(r)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to read ccs file %s: %w", fn, err)
 	}
-	return nil
+
+	return ccs, nil
 }
 
-func WriteProof(proof groth16.Proof, fn string) error {
-	openFile, err := OpenFileOnCreateOrOverwrite(fn)
+// CircuitStats summarizes the size of a constraint system.
+type CircuitStats struct {
+	NbConstraints       int
+	NbInternalVariables int
+	// NbPublicVariables includes the constant one wire gnark places first.
+	NbPublicVariables int
+	NbSecretVariables int
+	NbCoefficients    int
+}
+
+// CcsStats reports the CircuitStats of ccs.
+func CcsStats(ccs constraint.ConstraintSystem) CircuitStats {
+	return CircuitStats{
+		NbConstraints:       ccs.GetNbConstraints(),
+		NbInternalVariables: ccs.GetNbInternalVariables(),
+		NbPublicVariables:   ccs.GetNbPublicVariables(),
+		NbSecretVariables:   ccs.GetNbSecretVariables(),
+		NbCoefficients:      ccs.GetNbCoefficients(),
+	}
+}
+
+// CcsStatsFromFile loads the ccs written by WriteCcs or WriteCcsGzip to fn and reports its CircuitStats.
+func CcsStatsFromFile(fn string) (CircuitStats, error) {
+	ccs, err := ReadCcsAuto(fn)
 	if err != nil {
-		return err
+		return CircuitStats{}, err
+	}
+	return CcsStats(ccs), nil
+}
+
+// Diff tells how two constraint systems differ, as reported by CcsDiff.
+type Diff struct {
+	A, B CircuitStats
+	// Deep is set when the constraints themselves were compared, see WithDeepDiff.
+	Deep bool
+	// FirstDifferingConstraint is the index of the first constraint that differs between A and B, or the
+	// number of constraints of the shorter system if one is a prefix of the other. It is -1 if every
+	// constraint matches or if Deep is not set.
+	FirstDifferingConstraint int
+}
+
+// Equal reports whether no difference was found.
+func (d Diff) Equal() bool {
+	return d.A == d.B && d.FirstDifferingConstraint < 0
+}
+
+func (d Diff) String() string {
+	if d.Equal() {
+		return "constraint systems match"
+	}
+	var diffs []string
+	for _, c := range []struct {
+		name string
+		a, b int
+	}{
+		{"constraints", d.A.NbConstraints, d.B.NbConstraints},
+		{"internal variables", d.A.NbInternalVariables, d.B.NbInternalVariables},
+		{"public variables", d.A.NbPublicVariables, d.B.NbPublicVariables},
+		{"secret variables", d.A.NbSecretVariables, d.B.NbSecretVariables},
+		{"coefficients", d.A.NbCoefficients, d.B.NbCoefficients},
+	} {
+		if c.a != c.b {
+			diffs = append(diffs, fmt.Sprintf("%s: %d != %d", c.name, c.a, c.b))
+		}
+	}
+	if d.FirstDifferingConstraint >= 0 {
+		diffs = append(diffs, fmt.Sprintf("first differing constraint: %d", d.FirstDifferingConstraint))
+	}
+	return strings.Join(diffs, ", ")
+}
+
+// DiffOption configures CcsDiff.
+type DiffOption func(*diffConfig)
+
+type diffConfig struct {
+	deep bool
+}
+
+// WithDeepDiff makes CcsDiff compare the constraints one by one to find the first that differs. This
+// renders every constraint of both systems, so it costs about as much as WriteCcsDebugJson.
+func WithDeepDiff() DiffOption {
+	return func(c *diffConfig) {
+		c.deep = true
+	}
+}
+
+// CcsDiff compares the sizes of two constraint systems, e.g. the ccs of two builds of the same circuit
+// to catch non-deterministic compilation. Only the CircuitStats are compared unless WithDeepDiff is given.
+// Constraints are compared by their rendering with coefficients and wires resolved, so two systems
+// that only order their coefficient tables differently still match.
+func CcsDiff(a, b constraint.ConstraintSystem, opts ...DiffOption) (Diff, error) {
+	var cfg diffConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if a.Field().Cmp(b.Field()) != 0 {
+		return Diff{}, fmt.Errorf("cannot compare constraint systems over different fields")
+	}
+	d := Diff{A: CcsStats(a), B: CcsStats(b), Deep: cfg.deep, FirstDifferingConstraint: -1}
+	if !cfg.deep {
+		return d, nil
 	}
-	defer func() {
-		_ = openFile.Close()
-	}()
 
-	_, err = proof.WriteTo(openFile)
+	ra, err := renderConstraints(a)
 	if err != nil {
-		return err
+		return Diff{}, err
+	}
+	rb, err := renderConstraints(b)
+	if err != nil {
+		return Diff{}, err
+	}
+	n := min(len(ra), len(rb))
+	for i := 0; i < n; i++ {
+		if ra[i] != rb[i] {
+			d.FirstDifferingConstraint = i
+			return d, nil
+		}
+	}
+	if len(ra) != len(rb) {
+		d.FirstDifferingConstraint = n
 	}
+	return d, nil
+}
 
-	return nil
+func renderConstraints(ccs constraint.ConstraintSystem) ([]string, error) {
+	switch cs := ccs.(type) {
+	case interface{ GetR1Cs() []constraint.R1C }:
+		r1cs := cs.GetR1Cs()
+		rendered := make([]string, len(r1cs))
+		for i := range r1cs {
+			rendered[i] = r1cs[i].String(ccs)
+		}
+		return rendered, nil
+	case interface{ GetSparseR1Cs() []constraint.SparseR1C }:
+		scs := cs.GetSparseR1Cs()
+		rendered := make([]string, len(scs))
+		for i := range scs {
+			rendered[i] = scs[i].String(ccs)
+		}
+		return rendered, nil
+	}
+	return nil, fmt.Errorf("unsupported constraint system type %T", ccs)
 }
 
-func ReadProof(fn string) (groth16.Proof, error) {
-	f, err := os.Open(fn)
+// WriteCcsGzip writes ccs gzip compressed at the default compression level.
+func WriteCcsGzip(ccs constraint.ConstraintSystem, fn string, opts ...WriteOption) error {
+	return WriteCcsGzipLevel(ccs, fn, gzip.DefaultCompression, opts...)
+}
+
+// WriteCcsGzipLevel writes ccs gzip compressed at the given compress/gzip level.
+func WriteCcsGzipLevel(ccs constraint.ConstraintSystem, fn string, level int, opts ...WriteOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		gzw, err := gzip.NewWriterLevel(w, level)
+		if err != nil {
+			return fmt.Errorf("failed to create gzip writer: %w", err)
+		}
+		if err := WriteCcsTo(gzw, ccs, opts...); err != nil {
+			_ = gzw.Close()
+			return err
+		}
+		return gzw.Close()
+	}, append([]WriteOption{withKind("ccs")}, opts...)...)
+}
+
+// ReadCcsGzip restores a BN254 R1CS constraint system written by WriteCcsGzip.
+func ReadCcsGzip(fn string, opts ...ReadOption) (constraint.ConstraintSystem, error) {
+	f, err := DefaultFilesystem.Open(fn)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to open ccs file %s: %w", fn, err)
 	}
 	defer func() {
 		_ = f.Close()
 	}()
 
-	var bn254Proof groth16_bn254.Proof
-	_, err = bn254Proof.ReadFrom(f)
+	r, err := newReadConfig(opts).wrapReader(f)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to stat ccs file %s: %w", fn, err)
 	}
 
-	return &bn254Proof, nil
-}
-
-const (
-	proofLen          = 8
-	eachCommitmentLen = 2
-	commitmentPokLen  = 2
-)
-
-func WriteProofInSolidity(proof groth16.Proof, fn string) error {
-	openFile, err := OpenFileOnCreateOrOverwrite(fn)
+	gzr, err := gzip.NewReader(r)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to open gzip stream of ccs file %s: %w", fn, err)
 	}
 	defer func() {
-		_ = openFile.Close()
+		_ = gzr.Close()
 	}()
 
-	_proof := proof.(*groth16_bn254.Proof)
-	commitmentsLen := len(_proof.Commitments)
+	ccs, err := readCcsFrom(gzr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ccs file %s: %w", fn, err)
+	}
 
-	var proofInSol [proofLen]*big.Int
-	proofInSol[0] = new(big.Int).SetBytes(_proof.Ar.X.Marshal())
-	proofInSol[1] = new(big.Int).SetBytes(_proof.Ar.Y.Marshal())
-	proofInSol[2] = new(big.Int).SetBytes(_proof.Bs.X.A1.Marshal())
-	proofInSol[3] = new(big.Int).SetBytes(_proof.Bs.X.A0.Marshal())
-	proofInSol[4] = new(big.Int).SetBytes(_proof.Bs.Y.A1.Marshal())
-	proofInSol[5] = new(big.Int).SetBytes(_proof.Bs.Y.A0.Marshal())
-	proofInSol[6] = new(big.Int).SetBytes(_proof.Krs.X.Marshal())
-	proofInSol[7] = new(big.Int).SetBytes(_proof.Krs.Y.Marshal())
+	logDebug("read ccs", "file", fn, "constraints", ccs.GetNbConstraints())
+	return ccs, nil
+}
 
-	_, err = openFile.WriteString(bigIntSliceToString(proofInSol[:]))
-	if err != nil {
-		return err
+// WriteCcsAuto writes ccs gzip compressed if fn ends in .gz, and raw otherwise.
+func WriteCcsAuto(ccs constraint.ConstraintSystem, fn string, opts ...WriteOption) error {
+	if isGzipFilename(fn) {
+		return WriteCcsGzip(ccs, fn, opts...)
 	}
+	return WriteCcs(ccs, fn, opts...)
+}
 
-	commitmentsInSol := make([]*big.Int, commitmentsLen*eachCommitmentLen)
-	for i := 0; i < commitmentsLen; i++ {
-		commitmentsInSol[i*eachCommitmentLen] = new(big.Int).SetBytes(_proof.Commitments[i].X.Marshal())
-		commitmentsInSol[i*eachCommitmentLen+1] = new(big.Int).SetBytes(_proof.Commitments[i].Y.Marshal())
+// ReadCcsAuto reads a gzip compressed ccs if fn ends in .gz, and a raw one otherwise.
+func ReadCcsAuto(fn string, opts ...ReadOption) (constraint.ConstraintSystem, error) {
+	if isGzipFilename(fn) {
+		return ReadCcsGzip(fn, opts...)
 	}
+	return ReadCcs(fn, opts...)
+}
+
+func isGzipFilename(fn string) bool {
+	return strings.EqualFold(filepath.Ext(fn), ".gz")
+}
+
+// gzipMagic starts every gzip stream, RFC 1952: the ID1 and ID2 bytes and the deflate compression method.
+var gzipMagic = []byte{0x1f, 0x8b, 0x08}
 
-	_, err = openFile.WriteString("\n" + bigIntSliceToString(commitmentsInSol[:]))
+// sniffGzip returns a reader of the decompressed content of r if r holds a gzip stream, and of r as is
+// otherwise, along with a func releasing the decompressor. The start of r is only peeked through a
+// bufio.Reader, the raw path reads every byte of r. The magic could also start a raw uncompressed point
+// encoding, so r is only taken as gzip if the whole gzip header parses.
+func sniffGzip(r io.Reader) (io.Reader, func() error, error) {
+	br := bufio.NewReader(r)
+	noop := func() error { return nil }
+	if magic, _ := br.Peek(len(gzipMagic)); !bytes.Equal(magic, gzipMagic) {
+		return br, noop, nil
+	}
+	head, _ := br.Peek(br.Size())
+	if _, err := gzip.NewReader(bytes.NewReader(head)); err != nil {
+		return br, noop, nil
+	}
+	gzr, err := gzip.NewReader(br)
 	if err != nil {
-		return err
+		return nil, nil, fmt.Errorf("failed to open gzip stream: %w", err)
 	}
+	return gzr, gzr.Close, nil
+}
 
-	var commitmentPokInSol [commitmentPokLen]*big.Int
-	commitmentPokInSol[0] = new(big.Int).SetBytes(_proof.CommitmentPok.X.Marshal())
-	commitmentPokInSol[1] = new(big.Int).SetBytes(_proof.CommitmentPok.Y.Marshal())
+func WriteVkInSolidity(vk groth16.VerifyingKey, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteVkInSolidityTo(w, vk)
+	})
+}
 
-	_, err = openFile.WriteString("\n" + bigIntSliceToString(commitmentPokInSol[:]))
-	if err != nil {
+// WriteVkInSolidityTo streams the Solidity verifier contract for vk to w.
+func WriteVkInSolidityTo(w io.Writer, vk groth16.VerifyingKey) error {
+	if err := requireBN254(vk.CurveID()); err != nil {
 		return err
 	}
+	return vk.ExportSolidity(w)
+}
 
-	return nil
+var (
+	pragmaPattern        = regexp.MustCompile(`(?m)^pragma solidity [^;\n]+;`)
+	contractPattern      = regexp.MustCompile(`(?m)^contract Verifier\b`)
+	commitmentComment    = regexp.MustCompile(`(?mi)^[ \t]*//[^\n]*commitment[^\n]*\n`)
+	commitmentCode       = regexp.MustCompile(`(?i)commitment|pedersen`)
+	identifierPattern    = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	pragmaVersionPattern = regexp.MustCompile(`^[0-9A-Za-z.^~<>=| -]+$`)
+)
+
+// WriteVkInSolidityWithOptions is WriteVkInSolidity post-processing the exported contract with the
+// WithPragma, WithContractName and WithoutCommitmentSupport options, e.g. to pin the solc version a project
+// compiles with.
+func WriteVkInSolidityWithOptions(vk groth16.VerifyingKey, fn string, opts ...SolidityOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteVkInSolidityWithOptionsTo(w, vk, opts...)
+	})
 }
 
-func bigIntSliceToString(nums []*big.Int) string {
-	var sb strings.Builder
-	sb.WriteString("[")
-	for i, n := range nums {
-		if i > 0 {
-			sb.WriteString(",")
+// WriteVkInSolidityWithOptionsTo streams the post-processed Solidity verifier contract for vk to w.
+func WriteVkInSolidityWithOptionsTo(w io.Writer, vk groth16.VerifyingKey, opts ...SolidityOption) error {
+	cfg := newSolidityConfig(opts)
+
+	if cfg.minimal {
+		if _vk, ok := vk.(*groth16_bn254.VerifyingKey); ok && (len(_vk.CommitmentKeys) > 0 || len(_vk.PublicAndCommitmentCommitted) > 0) {
+			return fmt.Errorf("verifying key has %d commitments, cannot export a verifier without commitment support", len(_vk.PublicAndCommitmentCommitted))
 		}
-		sb.WriteString(n.String())
 	}
-	sb.WriteString("]")
-	return sb.String()
+
+	var buf bytes.Buffer
+	if err := WriteVkInSolidityTo(&buf, vk); err != nil {
+		return err
+	}
+	contract := buf.Bytes()
+
+	if cfg.minimal {
+		contract = commitmentComment.ReplaceAllLiteral(contract, nil)
+		if loc := commitmentCode.FindIndex(contract); loc != nil {
+			return fmt.Errorf("exported verifier still handles commitments at offset %d", loc[0])
+		}
+	}
+
+	if cfg.pragma != "" {
+		if !pragmaVersionPattern.MatchString(cfg.pragma) {
+			return fmt.Errorf("invalid pragma version %q", cfg.pragma)
+		}
+		if len(pragmaPattern.FindAllIndex(contract, -1)) != 1 {
+			return errors.New("expected exactly one pragma solidity line in the exported verifier")
+		}
+		contract = pragmaPattern.ReplaceAllLiteral(contract, []byte("pragma solidity "+cfg.pragma+";"))
+	}
+
+	if cfg.contractName != "" {
+		if !identifierPattern.MatchString(cfg.contractName) {
+			return fmt.Errorf("invalid contract name %q", cfg.contractName)
+		}
+		if len(contractPattern.FindAllIndex(contract, -1)) != 1 {
+			return errors.New("expected exactly one Verifier contract in the exported verifier")
+		}
+		contract = contractPattern.ReplaceAllLiteral(contract, []byte("contract "+cfg.contractName))
+	}
+
+	_, err := w.Write(contract)
+	return err
 }
 
-func WritePublicWitnessInJson(pw witness.Witness, fn string) error {
-	openFile, err := OpenFileOnCreateOrOverwrite(fn)
+func WriteVk(vk groth16.VerifyingKey, fn string, opts ...WriteOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteVkTo(w, vk)
+	}, append([]WriteOption{withKind("vk")}, opts...)...)
+}
+
+// WriteVkTo streams the binary serialization of vk to w.
+func WriteVkTo(w io.Writer, vk groth16.VerifyingKey) error {
+	_, err := vk.WriteTo(w)
+	return err
+}
+
+// ReadVk restores a BN254 verifying key written by WriteVk. Pass WithVkValidation to also check it with
+// ValidateVkStructure.
+func ReadVk(fn string, opts ...ReadOption) (groth16.VerifyingKey, error) {
+	return ReadVkWithCurve(fn, ecc.BN254, opts...)
+}
+
+// ReadVkWithCurve restores a verifying key on the given curve written by WriteVk, gzip compressed or not.
+func ReadVkWithCurve(fn string, curveID ecc.ID, opts ...ReadOption) (groth16.VerifyingKey, error) {
+	vk, err := readVkFS(pathFS(), fn, curveID, opts...)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	logDebug("read verifying key", "file", fn, "curve", curveID)
+	return vk, nil
+}
+
+// ReadVkFS restores a BN254 verifying key named name from fsys, e.g. an embed.FS or fstest.MapFS.
+func ReadVkFS(fsys fs.FS, name string) (groth16.VerifyingKey, error) {
+	return readVkFS(fsys, name, ecc.BN254)
+}
+
+func readVkFS(fsys fs.FS, fn string, curveID ecc.ID, opts ...ReadOption) (groth16.VerifyingKey, error) {
+	vk, err := newVerifyingKey(curveID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := fsys.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open verifying key file %s: %w", fn, err)
 	}
 	defer func() {
-		_ = openFile.Close()
+		_ = f.Close()
 	}()
 
-	pwStr := fmt.Sprint(pw.Vector())
-	_, err = openFile.WriteString(pwStr)
+	r, closeGzip, err := sniffGzip(f)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to read verifying key file %s: %w", fn, err)
 	}
+	defer func() {
+		_ = closeGzip()
+	}()
 
-	return nil
+	_, err = vk.ReadFrom(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verifying key file %s: %w", fn, err)
+	}
+
+	// NbPublicWitness is len(G1.K)-1, a negative count means the key holds no K points at all.
+	if vk.NbPublicWitness() < 0 {
+		return nil, fmt.Errorf("verifying key file %s is empty: no G1 K points", fn)
+	}
+	if newReadConfig(opts).validateVk {
+		if err := ValidateVkStructure(vk); err != nil {
+			return nil, fmt.Errorf("invalid verifying key in %s: %w", fn, err)
+		}
+	}
+
+	return vk, nil
+}
+
+// WithVkValidation makes ReadVk check the key it read with ValidateVkStructure, failing on a malformed key.
+func WithVkValidation() ReadOption {
+	return func(cfg *readConfig) {
+		cfg.validateVk = true
+	}
+}
+
+// ValidateVkStructure checks that a BN254 verifying key is well formed: every point is on the curve and in the
+// correct subgroup, Alpha, Beta, Gamma and Delta are not the point at infinity, and the G1.K array, one point
+// for the constant one wire, one per public input and one per commitment, agrees with the commitment
+// configuration: there is a commitment key per commitment, K holds a point per commitment on top of the
+// constant one, and every committed index is a public input or the input derived from an earlier commitment.
+// Whether K has the length a given circuit expects cannot be told from the key alone, see CheckVkMatchesCcs.
+func ValidateVkStructure(vk groth16.VerifyingKey) error {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("unsupported verifying key type %T, only BN254 is supported", vk)
+	}
+
+	nbCommitments := len(_vk.PublicAndCommitmentCommitted)
+	if len(_vk.CommitmentKeys) != nbCommitments {
+		return fmt.Errorf("verifying key has %d commitment keys for %d commitments", len(_vk.CommitmentKeys), nbCommitments)
+	}
+	if len(_vk.G1.K) < 1+nbCommitments {
+		return fmt.Errorf("verifying key has %d G1.K points, at least %d are needed for the constant and %d commitments", len(_vk.G1.K), 1+nbCommitments, nbCommitments)
+	}
+	nbPublic := len(_vk.G1.K) - 1 - nbCommitments
+	for i, committed := range _vk.PublicAndCommitmentCommitted {
+		for _, idx := range committed {
+			// indexes count the constant one wire, the inputs of earlier commitments follow the public ones
+			if idx < 1 || idx > nbPublic+i {
+				return fmt.Errorf("commitment %d commits to input %d, the key has %d public inputs", i, idx, nbPublic)
+			}
+		}
+	}
+
+	var invalid []string
+	checkG1 := func(name string, p *bn254.G1Affine) {
+		if !p.IsOnCurve() || !p.IsInSubGroup() {
+			invalid = append(invalid, name)
+		}
+	}
+	checkG2 := func(name string, p *bn254.G2Affine) {
+		if !p.IsOnCurve() || !p.IsInSubGroup() {
+			invalid = append(invalid, name)
+		}
+	}
+	checkG1("G1.Alpha", &_vk.G1.Alpha)
+	checkG2("G2.Beta", &_vk.G2.Beta)
+	checkG2("G2.Gamma", &_vk.G2.Gamma)
+	checkG2("G2.Delta", &_vk.G2.Delta)
+	for i := range _vk.G1.K {
+		checkG1(fmt.Sprintf("G1.K[%d]", i), &_vk.G1.K[i])
+	}
+	for i := range _vk.CommitmentKeys {
+		checkG2(fmt.Sprintf("CommitmentKeys[%d].G", i), &_vk.CommitmentKeys[i].G)
+		checkG2(fmt.Sprintf("CommitmentKeys[%d].GSigmaNeg", i), &_vk.CommitmentKeys[i].GSigmaNeg)
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("points not on curve or not in subgroup: %s", strings.Join(invalid, ", "))
+	}
+
+	var infinite []string
+	if _vk.G1.Alpha.IsInfinity() {
+		infinite = append(infinite, "G1.Alpha")
+	}
+	for _, p := range []struct {
+		name  string
+		point *bn254.G2Affine
+	}{{"G2.Beta", &_vk.G2.Beta}, {"G2.Gamma", &_vk.G2.Gamma}, {"G2.Delta", &_vk.G2.Delta}} {
+		if p.point.IsInfinity() {
+			infinite = append(infinite, p.name)
+		}
+	}
+	if len(infinite) > 0 {
+		return fmt.Errorf("points at infinity: %s", strings.Join(infinite, ", "))
+	}
+	return nil
+}
+
+// CheckVkMatchesCcs reports whether vk can verify proofs of ccs, comparing the curves, the number of public
+// inputs and, for BN254, the commitment configuration. A nil error does not prove vk came from a setup of
+// ccs, only that the shapes agree, which catches keys of a different circuit or of an older version of it.
+func CheckVkMatchesCcs(vk groth16.VerifyingKey, ccs constraint.ConstraintSystem) error {
+	if vk.CurveID().ScalarField().Cmp(ccs.Field()) != 0 {
+		return fmt.Errorf("verifying key is on %s, constraint system is not over its scalar field", vk.CurveID())
+	}
+	commitments, ok := ccs.GetCommitments().(constraint.Groth16Commitments)
+	if !ok {
+		return fmt.Errorf("constraint system holds %T commitments, not a groth16 circuit", ccs.GetCommitments())
+	}
+
+	_vk, isBN254 := vk.(*groth16_bn254.VerifyingKey)
+	if isBN254 && (len(_vk.PublicAndCommitmentCommitted) != len(commitments) || len(_vk.CommitmentKeys) != len(commitments)) {
+		return fmt.Errorf("verifying key has %d commitments, constraint system has %d", len(_vk.PublicAndCommitmentCommitted), len(commitments))
+	}
+
+	// Setup makes every commitment an extra public input after the public variables of the ccs, so the
+	// key holds one K point per public variable, counting the constant one wire, and one per commitment.
+	nbPublic := ccs.GetNbPublicVariables() - 1
+	if got := vk.NbPublicWitness() - len(commitments); got != nbPublic {
+		return fmt.Errorf("verifying key expects %d public inputs, constraint system has %d", got, nbPublic)
+	}
+	if !isBN254 {
+		return nil
+	}
+
+	want := commitments.GetPublicAndCommitmentCommitted(commitments.CommitmentIndexes(), ccs.GetNbPublicVariables())
+	for i := range want {
+		if !slices.Equal(_vk.PublicAndCommitmentCommitted[i], want[i]) {
+			return fmt.Errorf("commitment %d commits to public inputs %v in the verifying key, %v in the constraint system",
+				i, _vk.PublicAndCommitmentCommitted[i], want[i])
+		}
+	}
+	return nil
+}
+
+// WritePk writes pk with SensitiveFileMode permissions.
+func WritePk(pk groth16.ProvingKey, fn string, opts ...WriteOption) error {
+	return WriteAtomicWithMode(fn, SensitiveFileMode, func(w io.Writer) error {
+		return WritePkTo(w, pk)
+	}, append([]WriteOption{withKind("pk")}, opts...)...)
+}
+
+// WritePkTo streams the binary serialization of pk to w.
+func WritePkTo(w io.Writer, pk groth16.ProvingKey) error {
+	_, err := pk.WriteTo(w)
+	return err
+}
+
+// ReadPk restores a BN254 proving key written by WritePk.
+func ReadPk(fn string, opts ...ReadOption) (groth16.ProvingKey, error) {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open proving key file %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r, err := newReadConfig(opts).wrapReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat proving key file %s: %w", fn, err)
+	}
+
+	var bn254Pk groth16_bn254.ProvingKey
+	_, err = bn254Pk.ReadFrom(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proving key file %s: %w", fn, err)
+	}
+
+	logDebug("read proving key", "file", fn)
+	return &bn254Pk, nil
+}
+
+// WithVerifyAfterWrite makes WriteProof and WriteProofEncoded read the written file back, decode it and compare
+// its serialization byte for byte with the in-memory proof, catching corruption the filesystem did not report. It costs a
+// full read and decode of the proof.
+func WithVerifyAfterWrite() WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.verify = true
+	}
+}
+
+// WriteProof writes the binary serialization of proof, prefixed with a header when ProofFileFormat is ProofFormatV1.
+func WriteProof(proof groth16.Proof, fn string, opts ...WriteOption) error {
+	data, err := MarshalProof(proof)
+	if err != nil {
+		return err
+	}
+
+	opts = append([]WriteOption{withKind("proof")}, opts...)
+	err = WriteAtomic(fn, func(w io.Writer) error {
+		if ProofFileFormat == ProofFormatV1 {
+			if _, err := w.Write(proofHeader(proof.CurveID())); err != nil {
+				return err
+			}
+		}
+		_, err := w.Write(data)
+		return err
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	if !newWriteConfig(opts).verify || DryRun != nil {
+		return nil
+	}
+	return verifyWrittenProof(fn, proof.CurveID(), data)
+}
+
+// verifyWrittenProof checks that the proof stored in fn decodes and serializes back to want.
+func verifyWrittenProof(fn string, curveID ecc.ID, want []byte) error {
+	written, err := readFile(fn)
+	if err != nil {
+		return fmt.Errorf("failed to read back proof file %s: %w", fn, err)
+	}
+	return checkWrittenProof(fn, written, curveID, want)
+}
+
+// checkWrittenProof checks that written, the binary content read back from fn, decodes and serializes back
+// to want.
+func checkWrittenProof(fn string, written []byte, curveID ecc.ID, want []byte) error {
+	written, err := stripProofHeader(written, curveID)
+	if err != nil {
+		return fmt.Errorf("proof file %s is corrupted: %w", fn, err)
+	}
+
+	decoded, err := unmarshalProof(written, curveID)
+	if err != nil {
+		return fmt.Errorf("proof file %s is corrupted: %w", fn, err)
+	}
+	got, err := MarshalProof(decoded)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return fmt.Errorf("proof file %s is corrupted: read back proof differs from the written one", fn)
+	}
+
+	return nil
+}
+
+// WriteProofTo streams the binary serialization of proof to w.
+func WriteProofTo(w io.Writer, proof groth16.Proof) error {
+	_, err := proof.WriteTo(w)
+	return err
+}
+
+// MarshalProof returns the binary serialization of proof, as written by WriteProof.
+func MarshalProof(proof groth16.Proof) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := WriteProofTo(&buf, proof); err != nil {
+		return nil, fmt.Errorf("failed to marshal proof: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalProof restores a BN254 proof from the output of MarshalProof.
+func UnmarshalProof(data []byte) (groth16.Proof, error) {
+	return unmarshalProof(data, ecc.BN254)
+}
+
+func unmarshalProof(data []byte, curveID ecc.ID) (groth16.Proof, error) {
+	var (
+		proof groth16.Proof
+		n     int64
+		err   error
+	)
+	if curveID == ecc.BN254 {
+		r := bytes.NewReader(data)
+		proof, n, err = readBN254Proof(r, func() int64 { return int64(r.Len()) })
+	} else {
+		if proof, err = newProof(curveID); err != nil {
+			return nil, err
+		}
+		n, err = proof.ReadFrom(bytes.NewReader(data))
+	}
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("truncated proof, missing bytes after %d of %d: %w", n, len(data), io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if n != int64(len(data)) {
+		return nil, fmt.Errorf("proof holds %d trailing bytes after the %d bytes decoded", int64(len(data))-n, n)
+	}
+
+	return proof, nil
+}
+
+func ReadProof(fn string) (groth16.Proof, error) {
+	return ReadProofWithCurve(fn, ecc.BN254)
+}
+
+// ReadProofWithCurve restores a proof on the given curve written by WriteProof, gzip compressed or not.
+func ReadProofWithCurve(fn string, curveID ecc.ID) (groth16.Proof, error) {
+	proof, err := readProofFS(pathFS(), fn, curveID)
+	if err != nil {
+		return nil, err
+	}
+	logDebug("read proof", "file", fn, "curve", curveID)
+	return proof, nil
+}
+
+// ReadProofFS restores a BN254 proof named name from fsys, e.g. an embed.FS or fstest.MapFS.
+func ReadProofFS(fsys fs.FS, name string) (groth16.Proof, error) {
+	return readProofFS(fsys, name, ecc.BN254)
+}
+
+// ReadProofFrom decodes one BN254 proof from r, e.g. stdin or a network connection, reading at most maxBytes.
+// It stops right after the proof, so r may carry more data, and accepts proofs with or without the
+// ProofFormatV1 header. It returns ErrFileTooLarge if the limit is hit before the proof is complete, or if the
+// proof announces more commitments than the limit leaves room for, which are never allocated. The limit does
+// not bound the time spent waiting for r, set a deadline on the connection for that.
+func ReadProofFrom(r io.Reader, maxBytes int64) (groth16.Proof, error) {
+	lr := &io.LimitedReader{R: r, N: maxBytes}
+	limitErr := func(err error) error {
+		if lr.N <= 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			return fmt.Errorf("%w: proof exceeds the limit of %d bytes", ErrFileTooLarge, maxBytes)
+		}
+		return nil
+	}
+
+	// every proof is longer than a header, so the first bytes can be sniffed without overreading
+	head := make([]byte, ProofHeaderSize)
+	n, err := io.ReadFull(lr, head)
+	if err != nil {
+		if err := limitErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("truncated proof of %d bytes: %w", n, io.ErrUnexpectedEOF)
+	}
+	var body io.Reader = io.MultiReader(bytes.NewReader(head), lr)
+	if hasProofHeader(head) {
+		if _, err := stripProofHeader(head, ecc.BN254); err != nil {
+			return nil, fmt.Errorf("failed to read proof: %w", err)
+		}
+		body = lr
+	}
+
+	proof, _, err := readBN254Proof(body, func() int64 { return lr.N })
+	if errors.Is(err, errCommitmentCount) {
+		return nil, fmt.Errorf("%w: %w", ErrFileTooLarge, err)
+	}
+	if err != nil {
+		if err := limitErr(err); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read proof: %w", err)
+	}
+	return proof, nil
+}
+
+// errCommitmentCount reports a commitment count the rest of the input cannot hold.
+var errCommitmentCount = errors.New("too many commitments")
+
+// readBN254Proof decodes a proof laid out as groth16_bn254.Proof.WriteTo and WriteRawTo write it, like its
+// ReadFrom does, except that the untrusted commitment count is checked against remaining, the number of bytes
+// r can still deliver, before allocating for the commitments. It returns the number of bytes read.
+func readBN254Proof(r io.Reader, remaining func() int64) (*groth16_bn254.Proof, int64, error) {
+	proof := &groth16_bn254.Proof{}
+	dec := bn254.NewDecoder(r)
+	for _, p := range []any{&proof.Ar, &proof.Bs, &proof.Krs} {
+		if err := dec.Decode(p); err != nil {
+			return nil, dec.BytesRead(), err
+		}
+	}
+
+	var count [4]byte
+	if n, err := io.ReadFull(r, count[:]); err != nil {
+		return nil, dec.BytesRead() + int64(n), err
+	}
+	nbCommitments := binary.BigEndian.Uint32(count[:])
+	// every commitment takes at least a compressed point, and the proof of knowledge follows them
+	if need := (int64(nbCommitments) + 1) * bn254.SizeOfG1AffineCompressed; need > remaining() {
+		return nil, dec.BytesRead() + 4, fmt.Errorf("%w: %d commitments do not fit in %d bytes: %w", errCommitmentCount, nbCommitments, remaining(), io.ErrUnexpectedEOF)
+	}
+	proof.Commitments = make([]bn254.G1Affine, nbCommitments)
+	for i := range proof.Commitments {
+		if err := dec.Decode(&proof.Commitments[i]); err != nil {
+			return nil, dec.BytesRead() + 4, err
+		}
+	}
+	if err := dec.Decode(&proof.CommitmentPok); err != nil {
+		return nil, dec.BytesRead() + 4, err
+	}
+	return proof, dec.BytesRead() + 4, nil
+}
+
+// readProofFS reads fn whole, so unmarshalProof can check its size against the bytes the decoder consumed.
+func readProofFS(fsys fs.FS, fn string, curveID ecc.ID) (groth16.Proof, error) {
+	data, err := fs.ReadFile(fsys, fn)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = gunzipProof(data); err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	data, err = stripProofHeader(data, curveID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+
+	proof, err := unmarshalProof(data, curveID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read proof file %s: %w", fn, err)
+	}
+	return proof, nil
+}
+
+// gunzipProof decompresses data if it is gzip compressed, up to MaxStreamedProofSize bytes.
+func gunzipProof(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, gzipMagic) {
+		return data, nil
+	}
+	r, closeGzip, err := sniffGzip(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = closeGzip()
+	}()
+	out, err := io.ReadAll(io.LimitReader(r, MaxStreamedProofSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress proof: %w", err)
+	}
+	if int64(len(out)) > MaxStreamedProofSize {
+		return nil, fmt.Errorf("%w: decompressed proof exceeds %d bytes", ErrFileTooLarge, MaxStreamedProofSize)
+	}
+	return out, nil
+}
+
+func newProof(curveID ecc.ID) (groth16.Proof, error) {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.Proof{}, nil
+	case ecc.BLS12_381:
+		return &groth16_bls12381.Proof{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", curveID)
+	}
+}
+
+func newVerifyingKey(curveID ecc.ID) (groth16.VerifyingKey, error) {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.VerifyingKey{}, nil
+	case ecc.BLS12_381:
+		return &groth16_bls12381.VerifyingKey{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported curve %s", curveID)
+	}
+}
+
+// requireBN254 guards the Solidity exporters, the EVM precompiles only support BN254.
+func requireBN254(curveID ecc.ID) error {
+	if curveID != ecc.BN254 {
+		return fmt.Errorf("solidity export is only supported on %s, got %s", ecc.BN254, curveID)
+	}
+	return nil
+}
+
+// ReadProofChecked reads a proof like ReadProof and additionally checks that every point of the proof
+// is on the curve and in the correct subgroup.
+func ReadProofChecked(fn string) (groth16.Proof, error) {
+	proof, err := ReadProof(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateProofPoints(proof.(*groth16_bn254.Proof)); err != nil {
+		return nil, fmt.Errorf("invalid proof in %s: %w", fn, err)
+	}
+
+	return proof, nil
+}
+
+// validateProofPoints reports every point of the proof that is not on the curve or not in the subgroup.
+// An empty Commitments slice is valid, the CommitmentPok is only checked when commitments are present.
+func validateProofPoints(proof *groth16_bn254.Proof) error {
+	var invalid []string
+	checkG1 := func(name string, p *bn254.G1Affine) {
+		if !p.IsOnCurve() || !p.IsInSubGroup() {
+			invalid = append(invalid, name)
+		}
+	}
+
+	checkG1("Ar", &proof.Ar)
+	if !proof.Bs.IsOnCurve() || !proof.Bs.IsInSubGroup() {
+		invalid = append(invalid, "Bs")
+	}
+	checkG1("Krs", &proof.Krs)
+	for i := range proof.Commitments {
+		checkG1(fmt.Sprintf("Commitments[%d]", i), &proof.Commitments[i])
+	}
+	if len(proof.Commitments) > 0 {
+		checkG1("CommitmentPok", &proof.CommitmentPok)
+	}
+
+	if len(invalid) > 0 {
+		return fmt.Errorf("points not on curve or not in subgroup: %s", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// Word counts of the uint256 arrays WriteProofInSolidity emits for a BN254 proof.
+const (
+	// ProofWordCount is the number of words of the proof array: Ar (x, y), Bs (x.A1, x.A0, y.A1, y.A0), Krs (x, y).
+	ProofWordCount = 8
+	// WordsPerCommitment is the number of words each Pedersen commitment adds to the commitments array.
+	WordsPerCommitment = 2
+	// CommitmentPokWordCount is the number of words of the commitment proof of knowledge.
+	CommitmentPokWordCount = 2
+	// WordSize is the byte size of a uint256 word.
+	WordSize = 32
+)
+
+// ProofSolidityLayout gives the byte offsets and lengths of the proof, commitments and commitment pok
+// sections when the words of a proof with NbCommitments commitments are laid out back to back.
+// Without commitments there is no pok section, matching WriteProofInSolidity which omits it.
+type ProofSolidityLayout struct {
+	NbCommitments       int
+	ProofOffset         int
+	ProofLen            int
+	CommitmentsOffset   int
+	CommitmentsLen      int
+	CommitmentPokOffset int
+	CommitmentPokLen    int
+	// Size is the total byte length of all sections.
+	Size int
+}
+
+// NewProofSolidityLayout returns the layout of a proof with nbCommitments commitments.
+func NewProofSolidityLayout(nbCommitments int) ProofSolidityLayout {
+	l := ProofSolidityLayout{
+		NbCommitments: nbCommitments,
+		ProofLen:      ProofWordCount * WordSize,
+	}
+	l.CommitmentsOffset = l.ProofOffset + l.ProofLen
+	l.CommitmentsLen = nbCommitments * WordsPerCommitment * WordSize
+	l.CommitmentPokOffset = l.CommitmentsOffset + l.CommitmentsLen
+	if nbCommitments > 0 {
+		l.CommitmentPokLen = CommitmentPokWordCount * WordSize
+	}
+	l.Size = l.CommitmentPokOffset + l.CommitmentPokLen
+	return l
+}
+
+// NumberFormat selects how the Solidity writers print uint256 values.
+type NumberFormat int
+
+const (
+	// Decimal prints values as base 10 integers, this is the default.
+	Decimal NumberFormat = iota
+	// Hex prints values as 0x prefixed hex, left padded to 32 bytes so they can be used as calldata words.
+	Hex
+)
+
+type solidityConfig struct {
+	format       NumberFormat
+	pragma       string
+	contractName string
+	verifyVk     groth16.VerifyingKey
+	minimal      bool
+}
+
+// SolidityOption configures the Solidity writers.
+type SolidityOption func(*solidityConfig)
+
+// WithNumberFormat selects the NumberFormat of the emitted values.
+func WithNumberFormat(format NumberFormat) SolidityOption {
+	return func(cfg *solidityConfig) {
+		cfg.format = format
+	}
+}
+
+// WithPragma replaces the version constraint of the verifier's pragma line, e.g. "0.8.19" emits
+// `pragma solidity 0.8.19;`. Only used by WriteVkInSolidityWithOptions.
+func WithPragma(version string) SolidityOption {
+	return func(cfg *solidityConfig) {
+		cfg.pragma = version
+	}
+}
+
+// WithContractName renames the verifier contract. Only used by WriteVkInSolidityWithOptions.
+func WithContractName(name string) SolidityOption {
+	return func(cfg *solidityConfig) {
+		cfg.contractName = name
+	}
+}
+
+// WithoutCommitmentSupport makes WriteVkInSolidityWithOptions refuse keys with Pedersen commitments and emit a
+// contract without any commitment handling, dropping the exporter's comments about commitments too. gnark only
+// emits the commitment checks, the commitments and commitmentPok arguments and the PEDERSEN_ constants for keys
+// that have commitments, so for a key without any this mostly guards a gas sensitive deployment: the export
+// fails if the circuit starts committing, instead of silently growing the verifier.
+func WithoutCommitmentSupport() SolidityOption {
+	return func(cfg *solidityConfig) {
+		cfg.minimal = true
+	}
+}
+
+// WithVerifyBeforeExport makes WriteVerifyCalldata and WriteSolidityBundle verify the proof against vk and the
+// public witness first, refusing to emit calldata that would revert on chain. The proof is verified as the
+// contract does with VerifyLoadedProofForSolidity, so a commitment proof must have been generated with
+// solidity.WithProverTargetSolidityVerifier.
+func WithVerifyBeforeExport(vk groth16.VerifyingKey) SolidityOption {
+	return func(cfg *solidityConfig) {
+		cfg.verifyVk = vk
+	}
+}
+
+func newSolidityConfig(opts []SolidityOption) solidityConfig {
+	cfg := solidityConfig{format: Decimal}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WriteProofInSolidity writes the proof, commitments and commitmentPok verifyProof arguments of proof to fn.
+// The public inputs derived from the commitments are not written, the verifier contract recomputes them,
+// see CommitmentPublicInputs. Coordinates are not range checked: Marshal reduces every element, even one
+// whose limbs were corrupted in memory, so each word is in the BN254 base field. The range is checked where
+// words are parsed back, by ReadProofFromSolidity, ValidateProofSolidityString and VerifyFromCalldata.
+func WriteProofInSolidity(proof groth16.Proof, fn string, opts ...SolidityOption) error {
+	proofInSol, err := ProofToSolidityString(proof, opts...)
+	if err != nil {
+		return err
+	}
+
+	return WriteAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, proofInSol)
+		return err
+	})
+}
+
+// DumpProofAnnotated writes every word of the BN254 proof WriteProofInSolidity exports to w, one per line as
+// its field name and 32 byte hex value, in the order of the Solidity export. It is meant for eyeballing
+// calldata discrepancies, a swapped Bs coordinate shows up as the A0 value on an A1 line.
+func DumpProofAnnotated(proof groth16.Proof, w io.Writer) error {
+	proofInSol, commitmentsInSol, commitmentPokInSol, err := proofToSolidityWords(proof)
+	if err != nil {
+		return err
+	}
+
+	line := func(name string, v *big.Int) error {
+		_, err := fmt.Fprintf(w, "%-18s 0x%064x\n", name, v)
+		return err
+	}
+	for i, v := range proofInSol {
+		if err := line(proofWordNames[i], v); err != nil {
+			return err
+		}
+	}
+	// as in WriteProofInSolidityTo, the commitmentPok is only part of the export with commitments
+	if len(commitmentsInSol) == 0 {
+		return nil
+	}
+	for i, v := range commitmentsInSol {
+		name := fmt.Sprintf("Commitments[%d].X", i/WordsPerCommitment)
+		if i%WordsPerCommitment == 1 {
+			name = fmt.Sprintf("Commitments[%d].Y", i/WordsPerCommitment)
+		}
+		if err := line(name, v); err != nil {
+			return err
+		}
+	}
+	if err := line("CommitmentPok.X", commitmentPokInSol[0]); err != nil {
+		return err
+	}
+	return line("CommitmentPok.Y", commitmentPokInSol[1])
+}
+
+// ProofToSolidityString returns the Solidity representation of proof written by WriteProofInSolidity,
+// e.g. to embed it into a template.
+func ProofToSolidityString(proof groth16.Proof, opts ...SolidityOption) (string, error) {
+	var sb strings.Builder
+	if err := WriteProofInSolidityTo(&sb, proof, opts...); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// WriteProofInSolidityTo streams the Solidity representation of proof to w: the proof, commitments and
+// commitmentPok arrays on three lines, or only the proof line when the proof has no commitments.
+func WriteProofInSolidityTo(w io.Writer, proof groth16.Proof, opts ...SolidityOption) error {
+	cfg := newSolidityConfig(opts)
+
+	proofInSol, commitmentsInSol, commitmentPokInSol, err := proofToSolidityWords(proof)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, bigIntSliceToString(proofInSol[:], cfg.format))
+	if err != nil {
+		return err
+	}
+
+	// gnark's verifier drops the commitments and commitmentPok arguments for circuits without commitments
+	if len(commitmentsInSol) == 0 {
+		return nil
+	}
+
+	_, err = io.WriteString(w, "\n"+bigIntSliceToString(commitmentsInSol, cfg.format))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n"+bigIntSliceToString(commitmentPokInSol[:], cfg.format))
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// evmG2Names names the coordinates of Bs in the order of NormalizeG2ForEVM.
+var evmG2Names = [4]string{"Bs.X.A1", "Bs.X.A0", "Bs.Y.A1", "Bs.Y.A0"}
+
+// proofWordNames names the words of the proof argument of the verifier contract.
+var proofWordNames = [ProofWordCount]string{
+	"Ar.X", "Ar.Y", evmG2Names[0], evmG2Names[1], evmG2Names[2], evmG2Names[3], "Krs.X", "Krs.Y",
+}
+
+// NormalizeG2ForEVM returns the coordinates of p in the order the EVM pairing precompile (EIP-197) and gnark's
+// verifier contract expect them: (X.A1, X.A0, Y.A1, Y.A0), the imaginary part of each Fp2 coordinate first.
+// gnark stores an Fp2 element as A0 + A1*u and both its binary serialization and snarkjs JSON keep the
+// (A0, A1) order, so coordinates copied from them verbatim end up swapped and the pairing check fails.
+func NormalizeG2ForEVM(p *bn254.G2Affine) [4]*big.Int {
+	return [4]*big.Int{
+		p.X.A1.BigInt(new(big.Int)),
+		p.X.A0.BigInt(new(big.Int)),
+		p.Y.A1.BigInt(new(big.Int)),
+		p.Y.A0.BigInt(new(big.Int)),
+	}
+}
+
+// g2FromEVM is the inverse of NormalizeG2ForEVM. It does not check that the point is on the curve.
+func g2FromEVM(words []*big.Int) bn254.G2Affine {
+	var p bn254.G2Affine
+	p.X.A1.SetBigInt(words[0])
+	p.X.A0.SetBigInt(words[1])
+	p.Y.A1.SetBigInt(words[2])
+	p.Y.A0.SetBigInt(words[3])
+	return p
+}
+
+// proofToSolidityWords splits a BN254 proof into the proof, commitments and commitmentPok arguments
+// of the gnark exported verifier. The G2 point Bs is laid out by NormalizeG2ForEVM.
+func proofToSolidityWords(proof groth16.Proof) ([ProofWordCount]*big.Int, []*big.Int, [CommitmentPokWordCount]*big.Int, error) {
+	if err := requireBN254(proof.CurveID()); err != nil {
+		return [ProofWordCount]*big.Int{}, nil, [CommitmentPokWordCount]*big.Int{}, err
+	}
+	_proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return [ProofWordCount]*big.Int{}, nil, [CommitmentPokWordCount]*big.Int{}, fmt.Errorf("unsupported proof type %T, expected *groth16_bn254.Proof", proof)
+	}
+	commitmentsLen := len(_proof.Commitments)
+
+	// Marshal writes the canonical, reduced form of an element, so every word is in the base field: coordinates
+	// are range checked where words are parsed back, by checkSolidityWords and ReadVkFromSolidity.
+	word := func(e *fp.Element) *big.Int {
+		return new(big.Int).SetBytes(e.Marshal())
+	}
+
+	var proofInSol [ProofWordCount]*big.Int
+	proofInSol[0] = word(&_proof.Ar.X)
+	proofInSol[1] = word(&_proof.Ar.Y)
+	bs := NormalizeG2ForEVM(&_proof.Bs)
+	copy(proofInSol[2:6], bs[:])
+	proofInSol[6] = word(&_proof.Krs.X)
+	proofInSol[7] = word(&_proof.Krs.Y)
+
+	commitmentsInSol := make([]*big.Int, commitmentsLen*WordsPerCommitment)
+	for i := 0; i < commitmentsLen; i++ {
+		commitmentsInSol[i*WordsPerCommitment] = word(&_proof.Commitments[i].X)
+		commitmentsInSol[i*WordsPerCommitment+1] = word(&_proof.Commitments[i].Y)
+	}
+
+	var commitmentPokInSol [CommitmentPokWordCount]*big.Int
+	commitmentPokInSol[0] = word(&_proof.CommitmentPok.X)
+	commitmentPokInSol[1] = word(&_proof.CommitmentPok.Y)
+
+	return proofInSol, commitmentsInSol, commitmentPokInSol, nil
+}
+
+// checkBaseField rejects a coordinate parsed by ReadVkFromSolidity that is not a canonical element of the
+// BN254 base field, which SetBigInt would otherwise silently reduce to a different point.
+func checkBaseField(name string, v *big.Int) error {
+	if v.Sign() < 0 || v.Cmp(fp.Modulus()) >= 0 {
+		return fmt.Errorf("coordinate %s is not in the BN254 base field: %s", name, v)
+	}
+	return nil
+}
+
+var (
+	// ErrWordCount is returned when an array of a Solidity proof holds the wrong number of elements.
+	ErrWordCount = errors.New("wrong number of elements")
+	// ErrUnparseableWord is returned when an element of a Solidity proof is not a decimal or 0x hex integer.
+	ErrUnparseableWord = errors.New("unparseable element")
+	// ErrWordOutOfRange is returned when an element of a Solidity proof is not in the BN254 base field.
+	ErrWordOutOfRange = errors.New("element out of range")
+)
+
+// ValidateProofSolidityString checks the shape of s, a proof in the format written by WriteProofInSolidity,
+// without decoding points: one line with the 8 proof words, or three lines adding the commitments, a multiple
+// of 2 words, and the 2 words of the commitment pok. Every word must be a decimal or 0x hex integer in the
+// BN254 base field. Errors wrap ErrWordCount, ErrUnparseableWord or ErrWordOutOfRange.
+func ValidateProofSolidityString(s string) error {
+	_, _, _, err := parseProofSolidityWords(s)
+	return err
+}
+
+// parseProofSolidityWords splits s into the proof, commitments and commitment pok words, checking their
+// counts and ranges. A single line stands for a proof without commitments, with a zero pok.
+func parseProofSolidityWords(s string) ([]*big.Int, []*big.Int, []*big.Int, error) {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	if len(lines) == 1 {
+		lines = append(lines, "[]", "[0,0]")
+	}
+	if len(lines) != 3 {
+		return nil, nil, nil, fmt.Errorf("expected 1 or 3 lines, got %d", len(lines))
+	}
+
+	var words [3][]*big.Int
+	for i, section := range solidityWordSections {
+		nums, err := parseBigIntArray(lines[i])
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to parse %s: %w", section, err)
+		}
+		words[i] = nums
+	}
+	if err := checkSolidityWords(words[0], words[1], words[2]); err != nil {
+		return nil, nil, nil, err
+	}
+
+	return words[0], words[1], words[2], nil
+}
+
+// checkSolidityWords checks the proof, commitments and commitment pok arguments of the verifier contract
+// hold the expected number of words, each in the BN254 base field.
+func checkSolidityWords(proofWords, commitments, pok []*big.Int) error {
+	for i, section := range [][]*big.Int{proofWords, commitments, pok} {
+		for j, n := range section {
+			if n == nil || n.Sign() < 0 || n.Cmp(fp.Modulus()) >= 0 {
+				return fmt.Errorf("%w: %s element %d is not in the BN254 base field: %v", ErrWordOutOfRange, solidityWordSections[i], j, n)
+			}
+		}
+	}
+
+	if len(proofWords) != ProofWordCount {
+		return fmt.Errorf("%w: expected %d proof elements, got %d", ErrWordCount, ProofWordCount, len(proofWords))
+	}
+	if len(commitments)%WordsPerCommitment != 0 {
+		return fmt.Errorf("%w: expected a multiple of %d commitment elements, got %d", ErrWordCount, WordsPerCommitment, len(commitments))
+	}
+	if len(pok) != CommitmentPokWordCount {
+		return fmt.Errorf("%w: expected %d commitment pok elements, got %d", ErrWordCount, CommitmentPokWordCount, len(pok))
+	}
+	return nil
+}
+
+var solidityWordSections = [3]string{"proof", "commitments", "commitment pok"}
+
+// proofFromSolidityWords is the inverse of proofToSolidityWords for words checked by checkSolidityWords.
+// It checks the points of the proof with validateProofPoints.
+func proofFromSolidityWords(proofWords, commitments, pok []*big.Int) (*groth16_bn254.Proof, error) {
+	var proof groth16_bn254.Proof
+	proof.Ar.X.SetBigInt(proofWords[0])
+	proof.Ar.Y.SetBigInt(proofWords[1])
+	proof.Bs = g2FromEVM(proofWords[2:6])
+	proof.Krs.X.SetBigInt(proofWords[6])
+	proof.Krs.Y.SetBigInt(proofWords[7])
+
+	proof.Commitments = make([]bn254.G1Affine, len(commitments)/WordsPerCommitment)
+	for i := range proof.Commitments {
+		proof.Commitments[i].X.SetBigInt(commitments[i*WordsPerCommitment])
+		proof.Commitments[i].Y.SetBigInt(commitments[i*WordsPerCommitment+1])
+	}
+
+	proof.CommitmentPok.X.SetBigInt(pok[0])
+	proof.CommitmentPok.Y.SetBigInt(pok[1])
+
+	if err := validateProofPoints(&proof); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// ReadProofFromSolidity parses the format written by WriteProofInSolidity back into a BN254 proof.
+// A single line holds a proof without commitments. Words may be decimal or 0x prefixed hex, as written with
+// either NumberFormat. The Bs coordinates are expected in the NormalizeG2ForEVM order, as written.
+func ReadProofFromSolidity(fn string) (groth16.Proof, error) {
+	data, err := readFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read solidity proof file %s: %w", fn, err)
+	}
+
+	proofInSol, commitmentsInSol, commitmentPokInSol, err := parseProofSolidityWords(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("invalid solidity proof file %s: %w", fn, err)
+	}
+
+	proof, err := proofFromSolidityWords(proofInSol, commitmentsInSol, commitmentPokInSol)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proof in %s: %w", fn, err)
+	}
+
+	return proof, nil
+}
+
+// parseBigIntArray parses a bracketed, comma separated list of decimal or 0x prefixed hex integers
+// as written by bigIntSliceToString.
+func parseBigIntArray(s string) ([]*big.Int, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "[") || !strings.HasSuffix(s, "]") {
+		return nil, fmt.Errorf("expected a bracketed array, got %q", s)
+	}
+	s = strings.TrimSpace(s[1 : len(s)-1])
+	if s == "" {
+		return []*big.Int{}, nil
+	}
+
+	tokens := strings.Split(s, ",")
+	nums := make([]*big.Int, len(tokens))
+	for i, token := range tokens {
+		n, ok := parseBigInt(strings.TrimSpace(token))
+		if !ok {
+			return nil, fmt.Errorf("%w: element %d is not an integer: %q", ErrUnparseableWord, i, token)
+		}
+		nums[i] = n
+	}
+	return nums, nil
+}
+
+// parseBigInt parses s as a 0x or 0X prefixed hex integer, or else as a decimal integer.
+func parseBigInt(s string) (*big.Int, bool) {
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X") {
+		return new(big.Int).SetString(s[2:], 16)
+	}
+	return new(big.Int).SetString(s, 10)
+}
+
+// verifyCalldata omits commitments and commitmentPok without commitments, like the verifyProof signature does.
+type verifyCalldata struct {
+	Proof         []string `json:"proof"`
+	Commitments   []string `json:"commitments,omitempty"`
+	CommitmentPok []string `json:"commitmentPok,omitempty"`
+	Input         []string `json:"input"`
+}
+
+// WriteVerifyCalldata writes the arguments of the gnark exported Verifier.sol verifyProof(proof, commitments, commitmentPok, input)
+// as a single JSON object of strings with keys proof, commitments, commitmentPok and input. Values are
+// decimal unless opts select the Hex NumberFormat.
+// input holds only the circuit public inputs: the contract derives the commitment public inputs itself,
+// see CommitmentPublicInputs.
+func WriteVerifyCalldata(proof groth16.Proof, publicWitness witness.Witness, fn string, opts ...SolidityOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteVerifyCalldataTo(w, proof, publicWitness, opts...)
+	})
+}
+
+// WriteVerifyCalldataTo streams the JSON verifyProof arguments to w.
+func WriteVerifyCalldataTo(w io.Writer, proof groth16.Proof, publicWitness witness.Witness, opts ...SolidityOption) error {
+	cfg := newSolidityConfig(opts)
+	if cfg.verifyVk != nil {
+		if err := CheckPublicInputCount(cfg.verifyVk, publicWitness); err != nil {
+			return fmt.Errorf("refusing to export calldata: %w", err)
+		}
+		if err := VerifyLoadedProofForSolidity(proof, cfg.verifyVk, publicWitness); err != nil {
+			return fmt.Errorf("refusing to export calldata: %w", err)
+		}
+	}
+
+	proofInSol, commitmentsInSol, commitmentPokInSol, err := proofToSolidityWords(proof)
+	if err != nil {
+		return err
+	}
+
+	input, err := witnessToBigInts(publicWitness)
+	if err != nil {
+		return err
+	}
+
+	calldata := verifyCalldata{
+		Proof: bigIntSliceToStrings(proofInSol[:], cfg.format),
+		Input: bigIntSliceToStrings(input, cfg.format),
+	}
+	if len(commitmentsInSol) > 0 {
+		calldata.Commitments = bigIntSliceToStrings(commitmentsInSol, cfg.format)
+		calldata.CommitmentPok = bigIntSliceToStrings(commitmentPokInSol[:], cfg.format)
+	}
+
+	calldataJson, err := json.Marshal(calldata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal verify calldata: %w", err)
+	}
+
+	_, err = w.Write(calldataJson)
+	return err
+}
+
+// CommitmentPublicInputs returns the public inputs gnark derives from the proof commitments, in commitment order.
+// Each value is keccak256(X || Y || committed public inputs) mod r, where X and Y are the 32 byte big-endian
+// coordinates of the commitment and the committed inputs are taken from publicWitness, followed by the values
+// derived for earlier commitments, as listed in the verifying key.
+// This matches the legacy keccak256 hash-to-field of solidity.WithProverTargetSolidityVerifier and of the
+// contract written by WriteVkInSolidity, which recomputes these values on chain: they are not part of the
+// verifyProof input array and proofs must be generated with that hash for the contract to accept them.
+func CommitmentPublicInputs(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) ([]*big.Int, error) {
+	return commitmentPublicInputs(proof, vk, publicWitness, sha3.NewLegacyKeccak256())
+}
+
+// commitmentPublicInputs is CommitmentPublicInputs with the hash-to-field h, truncated to fr.Bytes as gnark does.
+func commitmentPublicInputs(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness, h hash.Hash) ([]*big.Int, error) {
+	_proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return nil, fmt.Errorf("expected a BN254 proof, got %T", proof)
+	}
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("expected a BN254 verifying key, got %T", vk)
+	}
+	if len(_proof.Commitments) != len(_vk.PublicAndCommitmentCommitted) {
+		return nil, fmt.Errorf("proof holds %d commitments, verifying key expects %d", len(_proof.Commitments), len(_vk.PublicAndCommitmentCommitted))
+	}
+
+	public, ok := publicWitness.Vector().(fr_bn254.Vector)
+	if !ok {
+		return nil, fmt.Errorf("expected a BN254 public witness, got %T", publicWitness.Vector())
+	}
+	// derived values are appended so that later commitments can reference them
+	public = append(fr_bn254.Vector(nil), public...)
+
+	values := make([]*big.Int, len(_proof.Commitments))
+	for i, committed := range _vk.PublicAndCommitmentCommitted {
+		h.Reset()
+		h.Write(_proof.Commitments[i].Marshal())
+		for _, idx := range committed {
+			// indexes count the constant one wire, which is not part of the public witness
+			if idx < 1 || idx > len(public) {
+				return nil, fmt.Errorf("commitment %d references public input %d out of %d", i, idx, len(public))
+			}
+			b := public[idx-1].Bytes()
+			h.Write(b[:])
+		}
+
+		var v fr_bn254.Element
+		v.SetBytes(h.Sum(nil)[:min(h.Size(), fr_bn254.Bytes)])
+		public = append(public, v)
+		values[i] = v.BigInt(new(big.Int))
+	}
+	return values, nil
+}
+
+func bigIntSliceToString(nums []*big.Int, format NumberFormat) string {
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i, n := range nums {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(formatBigInt(n, format))
+	}
+	sb.WriteString("]")
+	return sb.String()
+}
+
+func bigIntSliceToStrings(nums []*big.Int, format NumberFormat) []string {
+	res := make([]string, len(nums))
+	for i, n := range nums {
+		res[i] = formatBigInt(n, format)
+	}
+	return res
+}
+
+func formatBigInt(n *big.Int, format NumberFormat) string {
+	if format == Hex {
+		return fmt.Sprintf("0x%064x", n)
+	}
+	return n.String()
+}
+
+// PublicOnly returns a copy of the public part of w, dropping any secret assignments. It is a no-op copy for
+// a witness that is already public.
+func PublicOnly(w witness.Witness) (witness.Witness, error) {
+	pw, err := w.Public()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract public witness: %w", err)
+	}
+	return pw, nil
+}
+
+// WritePublicWitnessInJson writes the public inputs of pw as a JSON array of canonical decimal strings,
+// e.g. ["1","42"], so that EVM/JS tooling can consume it without losing precision.
+// Only PublicOnly(pw) is written: handed a full witness, the secret assignments are dropped, never serialized.
+func WritePublicWitnessInJson(pw witness.Witness, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WritePublicWitnessInJsonTo(w, pw)
+	})
+}
+
+// WritePublicWitnessInJsonTo streams the JSON representation of pw to w.
+func WritePublicWitnessInJsonTo(w io.Writer, pw witness.Witness) error {
+	publicWitness, err := PublicOnly(pw)
+	if err != nil {
+		return err
+	}
+	values, err := witnessToDecimalStrings(publicWitness)
+	if err != nil {
+		return err
+	}
+
+	pwJson, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal public witness: %w", err)
+	}
+
+	_, err = w.Write(pwJson)
+	return err
+}
+
+// WritePublicWitnessLabeled writes the public inputs of pw as a JSON object keyed by their names in s, in
+// public input order, e.g. {"Y":"9"}. Nested inputs use gnark's full names, such as "Inner_A" or "Arr_0".
+// With a nil schema it falls back to the positional array of WritePublicWitnessInJson.
+func WritePublicWitnessLabeled(pw witness.Witness, s *schema.Schema, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WritePublicWitnessLabeledTo(w, pw, s)
+	})
+}
+
+// WritePublicWitnessLabeledTo streams the labeled JSON representation of pw to w.
+func WritePublicWitnessLabeledTo(w io.Writer, pw witness.Witness, s *schema.Schema) error {
+	publicWitness, err := PublicOnly(pw)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return WritePublicWitnessInJsonTo(w, publicWitness)
+	}
+
+	values, err := witnessToDecimalStrings(publicWitness)
+	if err != nil {
+		return err
+	}
+	names, err := publicInputNames(s)
+	if err != nil {
+		return err
+	}
+	if len(names) != len(values) {
+		return fmt.Errorf("schema has %d public inputs, witness has %d", len(names), len(values))
+	}
+
+	// encode by hand, a map would lose the public input order
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := range names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(names[i])
+		if err != nil {
+			return fmt.Errorf("failed to marshal public input name %s: %w", names[i], err)
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		value, _ := json.Marshal(values[i])
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// PrintPublicWitness prints the public inputs of w as a table for humans to review, e.g. before signing a
+// transaction: the name of each input in s, its decimal value and its value as a 32 byte hex word, as the
+// verifier contract receives it. With a nil s, inputs are named by their index. Use WritePublicWitnessInJson
+// for machine readable output.
+func PrintPublicWitness(w witness.Witness, s *schema.Schema, out io.Writer) error {
+	publicWitness, err := PublicOnly(w)
+	if err != nil {
+		return err
+	}
+	values, err := witnessToBigInts(publicWitness)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	if s != nil {
+		if names, err = publicInputNames(s); err != nil {
+			return err
+		}
+		if len(names) != len(values) {
+			return fmt.Errorf("schema has %d public inputs, witness has %d", len(names), len(values))
+		}
+	} else {
+		names = make([]string, len(values))
+		for i := range names {
+			names[i] = strconv.Itoa(i)
+		}
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "NAME\tDECIMAL\tHEX")
+	for i, v := range values {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", names[i], formatBigInt(v, Decimal), formatBigInt(v, Hex))
+	}
+	return tw.Flush()
+}
+
+// publicInputNames returns the full names of the public leaves of s in witness order.
+func publicInputNames(s *schema.Schema) ([]string, error) {
+	leafType := reflect.TypeOf((*fr_bn254.Element)(nil))
+	var names []string
+	_, err := schema.Walk(s.Field, s.Instantiate(leafType), leafType, func(leaf schema.LeafInfo, _ reflect.Value) error {
+		if leaf.Visibility == schema.Public {
+			names = append(names, leaf.FullName())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk witness schema: %w", err)
+	}
+	return names, nil
+}
+
+// CanonicalizePublicWitness returns the public witness of w in gnark's canonical public input order, the order
+// of the input array of the verifier contract written by WriteVkInSolidity. That order lists the public leaves
+// of s in declaration order, struct fields depth first and arrays by index, wherever secret leaves are declared
+// between them, followed by the inputs derived from commitments, which are never part of a witness: the verifier
+// recomputes them. A witness carries no names, so w is interpreted by its length:
+//   - a full witness is in gnark's order, public leaves first then secret ones, and its public part is kept;
+//   - a public witness holding as many values as s has public leaves is already canonical;
+//   - a public witness holding a value for every leaf of s, public or secret, is taken to list them in
+//     declaration order, as tools ignoring visibility emit them, and its public leaves are picked out.
+//
+// Any other length is an error. Only BN254 witnesses are supported.
+func CanonicalizePublicWitness(w witness.Witness, s *schema.Schema) (witness.Witness, error) {
+	if s == nil {
+		return nil, errors.New("a schema is required to canonicalize a public witness")
+	}
+	publicWitness, err := PublicOnly(w)
+	if err != nil {
+		return nil, err
+	}
+	values, err := witnessToBigInts(publicWitness)
+	if err != nil {
+		return nil, err
+	}
+
+	leafType := reflect.TypeOf((*fr_bn254.Element)(nil))
+	var public []bool // visibility of every leaf in declaration order
+	_, err = schema.Walk(s.Field, s.Instantiate(leafType), leafType, func(leaf schema.LeafInfo, _ reflect.Value) error {
+		public = append(public, leaf.Visibility == schema.Public)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk witness schema: %w", err)
+	}
+
+	switch len(values) {
+	case s.NbPublic:
+	case len(public):
+		canonical := make([]*big.Int, 0, s.NbPublic)
+		for i, isPublic := range public {
+			if isPublic {
+				canonical = append(canonical, values[i])
+			}
+		}
+		values = canonical
+	default:
+		return nil, fmt.Errorf("public witness holds %d values, schema has %d public inputs out of %d", len(values), s.NbPublic, len(public))
+	}
+	return newPublicWitness(ecc.BN254, values)
+}
+
+// WritePublicWitnessCalldata writes the public inputs of pw as the abi encoded words of a uint256[] (without the
+// offset/length head), i.e. each element as a 32-byte big-endian word, concatenated and 0x prefixed.
+// Only PublicOnly(pw) is written, so secret assignments of a full witness are never emitted. The order is gnark's
+// public input order, which is the order the exported verifier expects its input array in.
+func WritePublicWitnessCalldata(pw witness.Witness, fn string) error {
+	publicWitness, err := PublicOnly(pw)
+	if err != nil {
+		return err
+	}
+
+	values, err := witnessToBigInts(publicWitness)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("0x")
+	for _, v := range values {
+		sb.WriteString(fmt.Sprintf("%064x", v))
+	}
+
+	return WriteAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, sb.String())
+		return err
+	})
+}
+
+// ReadPublicWitness restores a public witness written by WritePublicWitnessInJson.
+// The file is expected to hold a JSON array of decimal field elements.
+func ReadPublicWitness(fn string, curveID ecc.ID) (witness.Witness, error) {
+	data, err := readFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public witness file %s: %w", fn, err)
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public witness file %s: %w", fn, err)
+	}
+
+	elements := make([]*big.Int, len(values))
+	for i, v := range values {
+		n, ok := new(big.Int).SetString(v, 10)
+		if !ok {
+			return nil, fmt.Errorf("public witness element %d is not a decimal integer: %q", i, v)
+		}
+		elements[i] = n
+	}
+	return newPublicWitness(curveID, elements)
+}
+
+// newPublicWitness returns the public witness holding elements, which must be canonical elements of the
+// scalar field of curveID rather than be reduced silently.
+func newPublicWitness(curveID ecc.ID, elements []*big.Int) (witness.Witness, error) {
+	modulus := curveID.ScalarField()
+	for i, n := range elements {
+		if n.Sign() < 0 || n.Cmp(modulus) >= 0 {
+			return nil, fmt.Errorf("public witness element %d is out of field range: %s", i, n)
+		}
+	}
+
+	pw, err := witness.New(modulus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create witness: %w", err)
+	}
+
+	ch := make(chan any, len(elements))
+	for _, e := range elements {
+		ch <- e
+	}
+	close(ch)
+
+	if err := pw.Fill(len(elements), 0, ch); err != nil {
+		return nil, fmt.Errorf("failed to fill public witness: %w", err)
+	}
+
+	return pw, nil
+}
+
+// WriteWitness writes w, full or public only, in gnark's native binary witness encoding.
+func WriteWitness(w witness.Witness, fn string) error {
+	return WriteAtomic(fn, func(writer io.Writer) error {
+		_, err := w.WriteTo(writer)
+		return err
+	})
+}
+
+// ReadWitness restores a witness over the scalar field of curveID written by WriteWitness.
+func ReadWitness(fn string, curveID ecc.ID) (witness.Witness, error) {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open witness file %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	w, err := witness.New(curveID.ScalarField())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create witness: %w", err)
+	}
+	if _, err := w.ReadFrom(f); err != nil {
+		return nil, fmt.Errorf("failed to read witness file %s: %w", fn, err)
+	}
+
+	return w, nil
+}
+
+// ConvertWitnessToJson reads the binary BN254 witness wtnsFn and writes its public part to jsonFn in the
+// format of WritePublicWitnessInJson.
+func ConvertWitnessToJson(wtnsFn, jsonFn string) error {
+	w, err := ReadWitness(wtnsFn, ecc.BN254)
+	if err != nil {
+		return err
+	}
+
+	pw, err := PublicOnly(w)
+	if err != nil {
+		return fmt.Errorf("failed to convert %s: %w", wtnsFn, err)
+	}
+
+	return WritePublicWitnessInJson(pw, jsonFn)
+}
+
+// SplitWitness splits the full BN254 witness w of the circuit described by s into its public part, as
+// PublicOnly returns it, and a witness holding only its secret assignments, taking the public and secret counts
+// from s rather than from w. gnark fills a witness with every public leaf of s before every secret one, so
+// the split is exact once w is checked to hold s.NbPublic + s.NbSecret values, s.NbPublic of them public. It
+// fails for a nil schema and for any witness that does not match s, such as a public only one.
+func SplitWitness(w witness.Witness, s *schema.Schema) (public, private witness.Witness, err error) {
+	if s == nil {
+		return nil, nil, errors.New("a schema is required to split a witness")
+	}
+	vector, ok := w.Vector().(fr_bn254.Vector)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported witness vector type %T", w.Vector())
+	}
+	if len(vector) != s.NbPublic+s.NbSecret {
+		return nil, nil, fmt.Errorf("witness holds %d values, schema has %d public and %d secret leaves", len(vector), s.NbPublic, s.NbSecret)
+	}
+	public, err = PublicOnly(w)
+	if err != nil {
+		return nil, nil, err
+	}
+	if nbPublic := len(public.Vector().(fr_bn254.Vector)); nbPublic != s.NbPublic {
+		return nil, nil, fmt.Errorf("witness records %d public values, schema has %d public leaves", nbPublic, s.NbPublic)
+	}
+
+	secret := vector[s.NbPublic:]
+	private, err = witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create witness: %w", err)
+	}
+	ch := make(chan any, len(secret))
+	for _, v := range secret {
+		ch <- v
+	}
+	close(ch)
+	if err := private.Fill(0, len(secret), ch); err != nil {
+		return nil, nil, fmt.Errorf("failed to fill secret witness: %w", err)
+	}
+
+	return public, private, nil
+}
+
+// WriteSplitWitness splits w along s with SplitWitness and writes both parts in the encoding of WriteWitness, the
+// public part to publicFn and the secret one to privateFn with SensitiveFileMode permissions. Encrypt
+// privateFn before it leaves the machine, it holds the assignments in the clear.
+func WriteSplitWitness(w witness.Witness, s *schema.Schema, publicFn, privateFn string) error {
+	public, private, err := SplitWitness(w, s)
+	if err != nil {
+		return err
+	}
+	if err := WriteWitness(public, publicFn); err != nil {
+		return err
+	}
+	return WriteAtomicWithMode(privateFn, SensitiveFileMode, func(writer io.Writer) error {
+		_, err := private.WriteTo(writer)
+		return err
+	})
+}
+
+// ConcatPublicWitnesses concatenates the public parts of ws, which must all have the same number of public
+// inputs, into one BN254 public witness, e.g. for a batched verifier taking input[][] flattened.
+func ConcatPublicWitnesses(ws []witness.Witness) (witness.Witness, error) {
+	if len(ws) == 0 {
+		return nil, errors.New("no witnesses to concatenate")
+	}
+
+	var values []*big.Int
+	nbPublic := -1
+	for i, w := range ws {
+		pw, err := w.Public()
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract public part of witness %d: %w", i, err)
+		}
+		v, err := witnessToBigInts(pw)
+		if err != nil {
+			return nil, fmt.Errorf("witness %d: %w", i, err)
+		}
+		if nbPublic == -1 {
+			nbPublic = len(v)
+		} else if len(v) != nbPublic {
+			return nil, fmt.Errorf("witness %d has %d public inputs, witness 0 has %d", i, len(v), nbPublic)
+		}
+		values = append(values, v...)
+	}
+
+	merged, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create witness: %w", err)
+	}
+
+	ch := make(chan any, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+
+	if err := merged.Fill(len(values), 0, ch); err != nil {
+		return nil, fmt.Errorf("failed to fill public witness: %w", err)
+	}
+
+	return merged, nil
+}
+
+func witnessToDecimalStrings(w witness.Witness) ([]string, error) {
+	values, err := witnessToBigInts(w)
+	if err != nil {
+		return nil, err
+	}
+	return bigIntSliceToStrings(values, Decimal), nil
+}
+
+// witnessToBigInts converts each element of a BN254 witness to its canonical big.Int.
+// fr.Element.String is avoided on purpose since it prints elements close to the modulus as negatives.
+func witnessToBigInts(w witness.Witness) ([]*big.Int, error) {
+	vector, ok := w.Vector().(fr_bn254.Vector)
+	if !ok {
+		return nil, fmt.Errorf("unsupported witness vector type %T", w.Vector())
+	}
+
+	values := make([]*big.Int, len(vector))
+	for i := range vector {
+		values[i] = vector[i].BigInt(new(big.Int))
+	}
+	return values, nil
 }
@@ -1,16 +1,1952 @@
 package utilities
 
-import "testing"
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"testing/iotest"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"golang.org/x/crypto/sha3"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
 
 func Test_WriteProofInSolidity(t *testing.T) {
-	proof, err := ReadProof("./proof")
+	proof, _, _ := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "proof_solidity")
+	err := WriteProofInSolidity(proof, fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := ProofToSolidityString(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("expected the file to hold\n%s\ngot\n%s", want, got)
+	}
+	words, err := parseBigIntArray(strings.Split(want, "\n")[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(words) != ProofWordCount {
+		t.Fatalf("expected %d proof words, got %d", ProofWordCount, len(words))
+	}
+}
+
+func Test_OpenFileOnCreateOrOverwrite_CreatesMissingDirs(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "out", "proofs", "p1.bin")
+
+	f, err := OpenFileOnCreateOrOverwrite(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Name() != fn || filepath.Dir(f.TempName()) != filepath.Dir(fn) {
+		t.Fatalf("expected target %s and a temporary file next to it, got %s and %s", fn, f.Name(), f.TempName())
+	}
+	if _, err := os.Stat(f.TempName()); err != nil {
+		t.Fatalf("expected the temporary file to exist before Close: %v", err)
+	}
+	_ = f.Close()
+
+	exists, err := FileExists(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatalf("expected %s to exist", fn)
+	}
+}
+
+func Test_OpenFileOnCreateOrOverwrite_ConcurrentDirCreation(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "out", "proofs")
+
+	const n = 32
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- WriteAtomic(filepath.Join(dir, fmt.Sprintf("p%d.bin", i)), func(w io.Writer) error {
+				_, err := io.WriteString(w, "proof")
+				return err
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != n {
+		t.Fatalf("expected %d files, got %d", n, len(entries))
+	}
+}
+
+func Test_PublicWitnessJsonRoundTrip(t *testing.T) {
+	pw, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minusOne := new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+	values := []*big.Int{big.NewInt(0), big.NewInt(42), minusOne}
+	ch := make(chan any, len(values))
+	for _, v := range values {
+		ch <- v
+	}
+	close(ch)
+	if err := pw.Fill(len(values), 0, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "pub_in.json")
+	if err := WritePublicWitnessInJson(pw, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ReadPublicWitness(fn, ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, _ := pw.MarshalBinary()
+	got, _ := restored.MarshalBinary()
+	if !bytes.Equal(want, got) {
+		t.Fatalf("round trip mismatch: want %v, got %v", pw.Vector(), restored.Vector())
+	}
+}
+
+func Test_ReadPublicWitness_OutOfRange(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "pub_in.json")
+	modulus := ecc.BN254.ScalarField().String()
+	if err := os.WriteFile(fn, []byte(`["1","`+modulus+`"]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadPublicWitness(fn, ecc.BN254); err == nil {
+		t.Fatal("expected an error for an out of range element")
+	}
+}
+
+func Test_WriteAtomic_ReplacesExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "vk.bin")
+	if err := os.WriteFile(fn, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := WriteAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, "new")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new" {
+		t.Fatalf("expected file to be replaced, got %q", got)
+	}
+
+	err = WriteAtomic(fn, func(w io.Writer) error {
+		_, _ = io.WriteString(w, "partial")
+		return errors.New("write interrupted")
+	})
+	if err == nil {
+		t.Fatal("expected the write error to be returned")
+	}
+
+	got, err = os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "new" {
+		t.Fatalf("expected file to be untouched after a failed write, got %q", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func Test_WritePublicWitnessInJson_ValidJson(t *testing.T) {
+	pw, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	minusOne := new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+	ch := make(chan any, 2)
+	ch <- big.NewInt(7)
+	ch <- minusOne
+	close(ch)
+	if err := pw.Fill(2, 0, ch); err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "pub_in.json")
+	if err := WritePublicWitnessInJson(pw, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var values []string
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatalf("output is not valid JSON: %v (%s)", err, data)
+	}
+	if len(values) != 2 || values[0] != "7" || values[1] != minusOne.String() {
+		t.Fatalf("unexpected values %v", values)
+	}
+}
+
+// genTestProof proves 3*3 == 9 on BN254, see testutil.GenTestProof.
+func genTestProof(t testing.TB) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+	return testutil.GenTestProof(t)
+}
+
+func genTestProofOnCurve(t testing.TB, curveID ecc.ID) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+	return testutil.GenTestProofOnCurve(t, curveID)
+}
+
+func Test_WriteVerifyCalldata(t *testing.T) {
+	proof, _, publicWitness := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "calldata.json")
+	if err := WriteVerifyCalldata(proof, publicWitness, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calldata map[string][]string
+	if err := json.Unmarshal(data, &calldata); err != nil {
+		t.Fatal(err)
+	}
+	if len(calldata["proof"]) != ProofWordCount {
+		t.Fatalf("expected %d proof words, got %d", ProofWordCount, len(calldata["proof"]))
+	}
+	if _, ok := calldata["commitments"]; ok {
+		t.Fatal("expected commitments to be omitted for a proof without commitments")
+	}
+	if _, ok := calldata["commitmentPok"]; ok {
+		t.Fatal("expected commitmentPok to be omitted for a proof without commitments")
+	}
+	if len(calldata["input"]) != 1 || calldata["input"][0] != "9" {
+		t.Fatalf("unexpected input %v", calldata["input"])
+	}
+}
+
+func Test_ReadProofChecked(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "proof")
+	if err := WriteProof(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofChecked(fn); err != nil {
+		t.Fatal(err)
+	}
+
+	bn254Proof := proof.(*groth16_bn254.Proof)
+	if err := validateProofPoints(bn254Proof); err != nil {
+		t.Fatalf("expected an empty commitments slice to be valid: %v", err)
+	}
+
+	offCurve := bn254Proof.Ar
+	offCurve.Y.SetOne()
+	bn254Proof.Commitments = []bn254.G1Affine{offCurve}
+	err := validateProofPoints(bn254Proof)
+	if err == nil || !strings.Contains(err.Error(), "Commitments[0]") {
+		t.Fatalf("expected Commitments[0] to be reported, got %v", err)
+	}
+}
+
+func Test_ReadWithCurve_BLS12381(t *testing.T) {
+	proof, vk, publicWitness := genTestProofOnCurve(t, ecc.BLS12_381)
+	dir := t.TempDir()
+
+	proofFn := filepath.Join(dir, "proof")
+	if err := WriteProof(proof, proofFn); err != nil {
+		t.Fatal(err)
+	}
+	vkFn := filepath.Join(dir, "vk")
+	if err := WriteVk(vk, vkFn); err != nil {
+		t.Fatal(err)
+	}
+
+	restoredProof, err := ReadProofWithCurve(proofFn, ecc.BLS12_381)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredVk, err := ReadVkWithCurve(vkFn, ecc.BLS12_381)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(restoredProof, restoredVk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteProofInSolidity(restoredProof, filepath.Join(dir, "proof_solidity")); err == nil {
+		t.Fatal("expected solidity export to reject a BLS12-381 proof")
+	}
+}
+
+func Test_CcsRoundTrip(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "circuit.ccs")
+	if err := WriteCcs(ccs, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ReadCcs(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetNbConstraints() != ccs.GetNbConstraints() {
+		t.Fatalf("expected %d constraints, got %d", ccs.GetNbConstraints(), restored.GetNbConstraints())
+	}
+}
+
+func Test_ReadCcsLimited(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "circuit.ccs")
+	if err := WriteCcs(ccs, fn); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadCcsLimited(fn, int64(len(data))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadCcsLimited(fn, int64(len(data))-1); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected ErrFileTooLarge, got %v", err)
+	}
+
+	restored, err := ReadCcsFS(fstest.MapFS{"circuit.ccs": {Data: data}}, "circuit.ccs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.GetNbConstraints() != ccs.GetNbConstraints() {
+		t.Fatalf("expected %d constraints, got %d", ccs.GetNbConstraints(), restored.GetNbConstraints())
+	}
+}
+
+func Test_CcsStats(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	err = WriteProofInSolidity(proof, "./proof_solidity")
+	fn := filepath.Join(t.TempDir(), "circuit.ccs")
+	if err := WriteCcs(ccs, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := CcsStatsFromFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats != CcsStats(ccs) {
+		t.Fatalf("expected %+v, got %+v", CcsStats(ccs), stats)
+	}
+	if stats.NbPublicVariables != 2 || stats.NbSecretVariables != 1 {
+		t.Fatalf("expected the one wire and Y public, X secret: %+v", stats)
+	}
+}
+
+type offsetSquareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *offsetSquareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), api.Add(c.Y, 1))
+	return nil
+}
+
+func Test_CcsDiff(t *testing.T) {
+	compile := func(circuit frontend.Circuit) constraint.ConstraintSystem {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ccs
+	}
+	a, b := compile(&testutil.SquareCircuit{}), compile(&testutil.SquareCircuit{})
+
+	d, err := CcsDiff(a, b, WithDeepDiff())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.Equal() || !d.Deep {
+		t.Fatalf("expected two builds of the same circuit to match: %s", d)
+	}
+
+	offset := compile(&offsetSquareCircuit{})
+	d, err = CcsDiff(a, offset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.FirstDifferingConstraint != -1 || d.Deep {
+		t.Fatalf("expected constraints not to be compared by default: %+v", d)
+	}
+	d, err = CcsDiff(a, offset, WithDeepDiff())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Equal() || d.FirstDifferingConstraint < 0 {
+		t.Fatalf("expected a differing constraint: %+v", d)
+	}
+
+	committed := compile(&committedSquareCircuit{})
+	d, err = CcsDiff(a, committed, WithDeepDiff())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Equal() || d.A.NbConstraints == d.B.NbConstraints || !strings.Contains(d.String(), "constraints: ") {
+		t.Fatalf("expected the constraint counts to differ: %s", d)
+	}
+}
+
+func Test_CcsGzipRoundTrip(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	rawFn := filepath.Join(dir, "circuit.ccs")
+	gzFn := filepath.Join(dir, "circuit.ccs.gz")
+	if err := WriteCcsAuto(ccs, rawFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteCcsAuto(ccs, gzFn); err != nil {
+		t.Fatal(err)
+	}
+
+	// compiled systems carry caches that are not serialized, so compare against the raw decode
+	fromRaw, err := ReadCcsAuto(rawFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromGz, err := ReadCcsAuto(gzFn)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if !reflect.DeepEqual(fromRaw, fromGz) {
+		t.Fatal("gzip round trip did not reproduce the constraint system")
+	}
+}
+
+func Test_ReadSniffsGzip(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, vk, _ := genTestProof(t)
+
+	dir := t.TempDir()
+	write := func(name string, compress bool, to func(io.Writer) error) string {
+		var buf bytes.Buffer
+		if compress {
+			gzw := gzip.NewWriter(&buf)
+			if err := to(gzw); err != nil {
+				t.Fatal(err)
+			}
+			if err := gzw.Close(); err != nil {
+				t.Fatal(err)
+			}
+		} else if err := to(&buf); err != nil {
+			t.Fatal(err)
+		}
+		// no .gz extension, detection must not rely on it
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
 
+	for _, compress := range []bool{false, true} {
+		suffix := fmt.Sprintf("-%t", compress)
+		ccsFn := write("circuit.ccs"+suffix, compress, func(w io.Writer) error { return WriteCcsTo(w, ccs) })
+		vkFn := write("vk"+suffix, compress, func(w io.Writer) error { return WriteVkTo(w, vk) })
+		proofFn := write("proof"+suffix, compress, func(w io.Writer) error { return WriteProofTo(w, proof) })
+
+		restoredCcs, err := ReadCcs(ccsFn)
+		if err != nil {
+			t.Fatalf("compressed=%t: %v", compress, err)
+		}
+		if restoredCcs.GetNbConstraints() != ccs.GetNbConstraints() {
+			t.Fatalf("compressed=%t: ccs round trip changed the constraint count", compress)
+		}
+		if _, err := ReadCcsLimited(ccsFn, 1<<20); err != nil {
+			t.Fatalf("compressed=%t: %v", compress, err)
+		}
+		restoredVk, err := ReadVk(vkFn)
+		if err != nil {
+			t.Fatalf("compressed=%t: %v", compress, err)
+		}
+		if !restoredVk.(*groth16_bn254.VerifyingKey).G1.Alpha.Equal(&vk.(*groth16_bn254.VerifyingKey).G1.Alpha) {
+			t.Fatalf("compressed=%t: vk round trip changed the key", compress)
+		}
+		restoredProof, err := ReadProof(proofFn)
+		if err != nil {
+			t.Fatalf("compressed=%t: %v", compress, err)
+		}
+		if !restoredProof.(*groth16_bn254.Proof).Ar.Equal(&proof.(*groth16_bn254.Proof).Ar) {
+			t.Fatalf("compressed=%t: proof round trip changed the proof", compress)
+		}
+	}
+
+	// a raw stream starting with the magic but no valid gzip header is passed through whole
+	raw := append([]byte{0x1f, 0x8b, 0x08, 0xff}, bytes.Repeat([]byte{1}, 16)...)
+	r, closeGzip, err := sniffGzip(iotest.OneByteReader(bytes.NewReader(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = closeGzip()
+	}()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, raw) {
+		t.Fatalf("expected the raw stream back, got %x", got)
+	}
+}
+
+func Test_FileExists_PermissionError(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("permission bits are not enforced for root")
+	}
+
+	dir := filepath.Join(t.TempDir(), "locked")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(dir, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chmod(dir, 0o755)
+	})
+
+	_, err := FileExists(filepath.Join(dir, "proof"))
+	if !errors.Is(err, os.ErrPermission) {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+	if !errors.Is(err, ErrStat) {
+		t.Fatalf("expected ErrStat, got %v", err)
+	}
+}
+
+func Test_ReadProofFromSolidity(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "proof_solidity")
+	if err := WriteProofInSolidity(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ReadProofFromSolidity(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(restored, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	var want, got bytes.Buffer
+	_, _ = proof.WriteTo(&want)
+	_, _ = restored.WriteTo(&got)
+	if !bytes.Equal(want.Bytes(), got.Bytes()) {
+		t.Fatal("solidity round trip did not reproduce the proof")
+	}
+
+	if err := os.WriteFile(fn, []byte("[1,2,3]\n[]\n[0,0]"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofFromSolidity(fn); err == nil {
+		t.Fatal("expected an error for a short proof array")
+	}
+}
+
+func Test_WriteProofInSolidity_Hex(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "proof_solidity")
+	if err := WriteProofInSolidity(proof, fn, WithNumberFormat(Hex)); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstLine := strings.SplitN(string(data), "\n", 2)[0]
+	words := strings.Split(strings.Trim(firstLine, "[]"), ",")
+	if len(words) != ProofWordCount {
+		t.Fatalf("expected %d words, got %d", ProofWordCount, len(words))
+	}
+	for _, word := range words {
+		if !strings.HasPrefix(word, "0x") || len(word) != 2+64 {
+			t.Fatalf("expected a 32 byte 0x prefixed word, got %q", word)
+		}
+	}
+
+	if _, err := ReadProofFromSolidity(fn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_DumpProofAnnotated(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	var sb strings.Builder
+	if err := DumpProofAnnotated(proof, &sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+	if len(lines) != ProofWordCount {
+		t.Fatalf("expected %d lines without commitments, got %d:\n%s", ProofWordCount, len(lines), sb.String())
+	}
+	bs := proof.(*groth16_bn254.Proof).Bs
+	wantLine := fmt.Sprintf("Bs.X.A1            0x%064x", bs.X.A1.BigInt(new(big.Int)))
+	if lines[2] != wantLine {
+		t.Fatalf("expected the imaginary part of Bs.X third, got %q", lines[2])
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.Reset()
+	if err := DumpProofAnnotated(committed, &sb); err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSuffix(sb.String(), "\n"), "\n")
+
+	// the values follow the words of the Solidity export one for one
+	exported, err := ProofToSolidityString(committed, WithNumberFormat(Hex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var words []string
+	for _, l := range strings.Split(exported, "\n") {
+		words = append(words, strings.Split(strings.Trim(l, "[]"), ",")...)
+	}
+	if len(lines) != len(words) {
+		t.Fatalf("expected %d lines, got %d", len(words), len(lines))
+	}
+	wantNames := append(proofWordNames[:], "Commitments[0].X", "Commitments[0].Y", "CommitmentPok.X", "CommitmentPok.Y")
+	for i, l := range lines {
+		fields := strings.Fields(l)
+		if len(fields) != 2 || fields[0] != wantNames[i] || fields[1] != words[i] {
+			t.Fatalf("line %d: expected %s %s, got %q", i, wantNames[i], words[i], l)
+		}
+	}
+
+	blsProof, _, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if err := DumpProofAnnotated(blsProof, &sb); err == nil {
+		t.Fatal("expected a BLS12-381 proof to be rejected")
+	}
+}
+
+func Test_ReadProofFS(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	var proofBuf, vkBuf bytes.Buffer
+	if err := WriteProofTo(&proofBuf, proof); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVkTo(&vkBuf, vk); err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{
+		"fixtures/proof": {Data: proofBuf.Bytes()},
+		"fixtures/vk":    {Data: vkBuf.Bytes()},
+	}
+
+	restoredProof, err := ReadProofFS(fsys, "fixtures/proof")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restoredVk, err := ReadVkFS(fsys, "fixtures/vk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(restoredProof, restoredVk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_OpenFileOnCreateOrOverwrite_PreservesOriginalOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "proof")
+	if err := os.WriteFile(fn, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := OpenFileOnCreateOrOverwrite(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("partial"); err != nil {
+		t.Fatal(err)
+	}
+	// simulate the disk going away mid-write
+	_ = f.File.Close()
+	if _, err := f.WriteString("more"); err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if err := f.Close(); err == nil {
+		t.Fatal("expected Close to report the failed write")
+	}
+
+	got, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "original" {
+		t.Fatalf("expected the original file to be intact, got %q", got)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected temporary files to be cleaned up, found %d entries", len(entries))
+	}
+}
+
+func Test_WriteProofInSolidity_ZeroCommitments(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+
+	var buf bytes.Buffer
+	if err := WriteProofInSolidityTo(&buf, proof); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "\n") {
+		t.Fatalf("expected only the proof line without commitments, got %q", buf.String())
+	}
+
+	fn := filepath.Join(t.TempDir(), "proof_solidity")
+	if err := os.WriteFile(fn, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProofFromSolidity(fn); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// foreignProof reports BN254 but is not backed by *groth16_bn254.Proof.
+type foreignProof struct {
+	groth16.Proof
+}
+
+func Test_WriteProofInSolidity_NonBN254Proof(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+
+	var buf bytes.Buffer
+	err := WriteProofInSolidityTo(&buf, foreignProof{proof})
+	if err == nil || !strings.Contains(err.Error(), "unsupported proof type") {
+		t.Fatalf("expected an unsupported proof type error, got %v", err)
+	}
+}
+
+func Test_PkRoundTrip(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "pk")
+	if err := WritePk(pk, fn); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadPk(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fullWitness, err := frontend.NewWitness(&testutil.SquareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, restored, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_CheckVkMatchesCcs(t *testing.T) {
+	circuits := map[string]frontend.Circuit{
+		"square":        &testutil.SquareCircuit{},
+		"labeled":       &labeledCircuit{},
+		"committed":     &committedSquareCircuit{},
+		"public commit": &publicCommitCircuit{},
+	}
+	ccss := make(map[string]constraint.ConstraintSystem)
+	vks := make(map[string]groth16.VerifyingKey)
+	for name, circuit := range circuits {
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, vk, err := groth16.Setup(ccs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ccss[name], vks[name] = ccs, vk
+	}
+
+	for name := range circuits {
+		if err := CheckVkMatchesCcs(vks[name], ccss[name]); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+	}
+
+	for _, tt := range []struct{ vk, ccs, want string }{
+		{"square", "labeled", "public inputs"},
+		{"labeled", "square", "public inputs"},
+		{"committed", "square", "commitments"},
+		{"square", "committed", "commitments"},
+		{"committed", "public commit", "commitment 0 commits"},
+	} {
+		err := CheckVkMatchesCcs(vks[tt.vk], ccss[tt.ccs])
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Fatalf("vk of %s, ccs of %s: expected an error about %s, got %v", tt.vk, tt.ccs, tt.want, err)
+		}
+	}
+
+	_, blsVk, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if err := CheckVkMatchesCcs(blsVk, ccss["square"]); err == nil {
+		t.Fatal("expected a verifying key on another curve to be rejected")
+	}
+}
+
+func Test_ValidateVkStructure(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	if err := ValidateVkStructure(vk); err != nil {
+		t.Fatal(err)
+	}
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, committedVk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateVkStructure(committedVk); err != nil {
+		t.Fatal(err)
+	}
+
+	offCurve := *vk.(*groth16_bn254.VerifyingKey)
+	offCurve.G1.K = slices.Clone(offCurve.G1.K)
+	offCurve.G1.K[1].X.SetOne()
+	if err := ValidateVkStructure(&offCurve); err == nil || !strings.Contains(err.Error(), "G1.K[1]") {
+		t.Fatalf("expected G1.K[1] to be reported, got %v", err)
+	}
+	infinite := *vk.(*groth16_bn254.VerifyingKey)
+	infinite.G2.Delta = bn254.G2Affine{}
+	if err := ValidateVkStructure(&infinite); err == nil || !strings.Contains(err.Error(), "G2.Delta") {
+		t.Fatalf("expected G2.Delta to be reported, got %v", err)
+	}
+	missingKey := *committedVk.(*groth16_bn254.VerifyingKey)
+	missingKey.CommitmentKeys = nil
+	if err := ValidateVkStructure(&missingKey); err == nil {
+		t.Fatal("expected a commitment without key to be rejected")
+	}
+
+	// K one point short of the commitment, the kind of key a bad circuit change leaves behind
+	short := *committedVk.(*groth16_bn254.VerifyingKey)
+	short.G1.K = short.G1.K[:1]
+	if err := ValidateVkStructure(&short); err == nil {
+		t.Fatal("expected a K array without room for the commitment to be rejected")
+	}
+
+	// gnark decodes a key committing to an input it does not have, the option rejects it
+	outOfRange := *committedVk.(*groth16_bn254.VerifyingKey)
+	outOfRange.PublicAndCommitmentCommitted = [][]int{{5}}
+	fn := filepath.Join(t.TempDir(), "vk")
+	if err := WriteVk(&outOfRange, fn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVk(fn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVk(fn, WithVkValidation()); err == nil || !strings.Contains(err.Error(), "commits to input 5") {
+		t.Fatalf("expected the committed index to be rejected, got %v", err)
+	}
+
+	_, bls, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if err := ValidateVkStructure(bls); err == nil {
+		t.Fatal("expected a BLS12-381 key to be rejected")
+	}
+}
+
+func Test_FileModes(t *testing.T) {
+	dir := t.TempDir()
+
+	fn := filepath.Join(dir, "nested", "artifact")
+	err := WriteAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, "artifact")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != DefaultFileMode {
+		t.Fatalf("expected mode %v, got %v", DefaultFileMode, info.Mode().Perm())
+	}
+
+	secretFn := filepath.Join(dir, "secret")
+	err = WriteAtomicWithMode(secretFn, SensitiveFileMode, func(w io.Writer) error {
+		_, err := io.WriteString(w, "toxic waste")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err = os.Stat(secretFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != SensitiveFileMode {
+		t.Fatalf("expected mode %v, got %v", SensitiveFileMode, info.Mode().Perm())
+	}
+}
+
+func Test_MarshalProofRoundTrip(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	data, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalProof(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(restored, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_WritePublicWitnessCalldata(t *testing.T) {
+	fullWitness, err := frontend.NewWitness(&testutil.SquareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "input.hex")
+	if err := WritePublicWitnessCalldata(fullWitness, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "0x" + strings.Repeat("0", 63) + "9"
+	if string(got) != want {
+		t.Fatalf("expected only the public input %s, got %s", want, got)
+	}
+}
+
+func Test_NewProofSolidityLayout(t *testing.T) {
+	l := NewProofSolidityLayout(0)
+	if l.ProofLen != 256 || l.CommitmentsLen != 0 || l.CommitmentPokLen != 0 || l.Size != 256 {
+		t.Fatalf("unexpected layout without commitments: %+v", l)
+	}
+
+	l = NewProofSolidityLayout(2)
+	want := ProofSolidityLayout{
+		NbCommitments:       2,
+		ProofOffset:         0,
+		ProofLen:            256,
+		CommitmentsOffset:   256,
+		CommitmentsLen:      128,
+		CommitmentPokOffset: 384,
+		CommitmentPokLen:    64,
+		Size:                448,
+	}
+	if l != want {
+		t.Fatalf("expected %+v, got %+v", want, l)
+	}
+}
+
+type labeledCircuit struct {
+	Secret frontend.Variable
+	Inner  struct {
+		A frontend.Variable `gnark:",public"`
+	}
+	Arr [2]frontend.Variable `gnark:",public"`
+}
+
+func (c *labeledCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(c.Inner.A, c.Arr[0], c.Arr[1]), c.Secret)
+	return nil
+}
+
+func Test_WritePublicWitnessLabeled(t *testing.T) {
+	assignment := &labeledCircuit{Secret: 6, Arr: [2]frontend.Variable{2, 3}}
+	assignment.Inner.A = 1
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := frontend.NewSchema(ecc.BN254.ScalarField(), &labeledCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePublicWitnessLabeledTo(&buf, fullWitness, s); err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"Inner_A":"1","Arr_0":"2","Arr_1":"3"}`; buf.String() != want {
+		t.Fatalf("expected %s, got %s", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := WritePublicWitnessLabeledTo(&buf, fullWitness, nil); err != nil {
+		t.Fatal(err)
+	}
+	if want := `["1","2","3"]`; buf.String() != want {
+		t.Fatalf("expected positional fallback %s, got %s", want, buf.String())
+	}
+}
+
+func Test_PrintPublicWitness(t *testing.T) {
+	assignment := &labeledCircuit{Secret: 6, Arr: [2]frontend.Variable{2, 3}}
+	assignment.Inner.A = -1
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := frontend.NewSchema(ecc.BN254.ScalarField(), &labeledCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := PrintPublicWitness(fullWitness, s, &buf); err != nil {
+		t.Fatal(err)
+	}
+	minusOne := new(big.Int).Sub(ecc.BN254.ScalarField(), big.NewInt(1))
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 4 || strings.Fields(lines[0])[0] != "NAME" {
+		t.Fatalf("expected a header and 3 rows, got:\n%s", buf.String())
+	}
+	want := [][]string{
+		{"Inner_A", minusOne.String(), fmt.Sprintf("0x%064x", minusOne)},
+		{"Arr_0", "2", fmt.Sprintf("0x%064x", 2)},
+		{"Arr_1", "3", fmt.Sprintf("0x%064x", 3)},
+	}
+	for i, row := range want {
+		if got := strings.Fields(lines[i+1]); !reflect.DeepEqual(got, row) {
+			t.Fatalf("row %d: expected %v, got %v", i, row, got)
+		}
+	}
+	if strings.Contains(buf.String(), "Secret") {
+		t.Fatal("expected secret assignments to be left out")
+	}
+
+	buf.Reset()
+	if err := PrintPublicWitness(fullWitness, nil, &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if got := strings.Fields(lines[3]); got[0] != "2" || got[1] != "3" {
+		t.Fatalf("expected positional names, got %v", got)
+	}
+}
+
+func Test_CanonicalizePublicWitness(t *testing.T) {
+	assignment := &labeledCircuit{Secret: 6, Arr: [2]frontend.Variable{2, 3}}
+	assignment.Inner.A = 1
+	fullWitness, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := frontend.NewSchema(ecc.BN254.ScalarField(), &labeledCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*big.Int{big.NewInt(1), big.NewInt(2), big.NewInt(3)}
+
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every leaf in declaration order, the secret one first
+	declared, err := newPublicWitness(ecc.BN254, []*big.Int{big.NewInt(6), big.NewInt(1), big.NewInt(2), big.NewInt(3)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, w := range map[string]witness.Witness{"full": fullWitness, "public": publicWitness, "declared": declared} {
+		canonical, err := CanonicalizePublicWitness(w, s)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		got, err := witnessToBigInts(canonical)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+
+	short, err := newPublicWitness(ecc.BN254, want[:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CanonicalizePublicWitness(short, s); err == nil {
+		t.Fatal("expected a witness of another length to be rejected")
+	}
+	if _, err := CanonicalizePublicWitness(publicWitness, nil); err == nil {
+		t.Fatal("expected a nil schema to be rejected")
+	}
+}
+
+func Test_WriteProofInSolidity_NonReducedElement(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	// limbs above the modulus, as memory corruption would leave them, are still exported reduced
+	proof.(*groth16_bn254.Proof).Ar.X = fp.Element{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}
+
+	var buf bytes.Buffer
+	if err := WriteProofInSolidityTo(&buf, proof); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateProofSolidityString(buf.String()); err != nil {
+		t.Fatalf("expected every exported word in the base field, got %v", err)
+	}
+}
+
+func Test_WitnessBinaryRoundTrip(t *testing.T) {
+	fullWitness, err := frontend.NewWitness(&testutil.SquareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	wtnsFn := filepath.Join(dir, "witness.wtns")
+	if err := WriteWitness(fullWitness, wtnsFn); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := ReadWitness(wtnsFn, ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fullWitness.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := restored.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Fatal("binary witness round trip changed the witness")
+	}
+
+	jsonFn := filepath.Join(dir, "public.json")
+	if err := ConvertWitnessToJson(wtnsFn, jsonFn); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(jsonFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["9"]` {
+		t.Fatalf("expected only the public input, got %s", data)
+	}
+}
+
+// corruptingWriter flips a bit of the byte at offset, simulating corruption the OS did not report.
+type corruptingWriter struct {
+	w      io.Writer
+	offset int
+	n      int
+}
+
+func (cw *corruptingWriter) Write(p []byte) (int, error) {
+	buf := append([]byte(nil), p...)
+	if i := cw.offset - cw.n; i >= 0 && i < len(buf) {
+		buf[i] ^= 0x01
+	}
+	cw.n += len(p)
+	return cw.w.Write(buf)
+}
+
+// corruptingFilesystem corrupts the byte at offset of every file created exclusively, as the temporary
+// files of WriteAtomic are.
+type corruptingFilesystem struct {
+	Filesystem
+	offset int
+}
+
+func (f corruptingFilesystem) CreateExclusive(name string, perm fs.FileMode) (WritableFile, error) {
+	file, err := f.Filesystem.CreateExclusive(name, perm)
+	if err != nil {
+		return nil, err
+	}
+	return corruptingFile{WritableFile: file, w: &corruptingWriter{w: file, offset: f.offset}}, nil
+}
+
+type corruptingFile struct {
+	WritableFile
+	w io.Writer
+}
+
+func (f corruptingFile) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+func Test_WriteProof_VerifyAfterWrite(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	fn := filepath.Join(t.TempDir(), "proof")
+
+	if err := WriteProof(proof, fn, WithVerifyAfterWrite()); err != nil {
+		t.Fatal(err)
+	}
+
+	DefaultFilesystem = corruptingFilesystem{Filesystem: OSFilesystem{}, offset: 40}
+	defer func() { DefaultFilesystem = OSFilesystem{} }()
+	if err := WriteProof(proof, fn); err != nil {
+		t.Fatalf("expected the corruption to go unnoticed without verification, got %v", err)
+	}
+	if err := WriteProof(proof, fn, WithVerifyAfterWrite()); err == nil || !strings.Contains(err.Error(), "corrupted") {
+		t.Fatalf("expected verification to detect the corruption, got %v", err)
+	}
+}
+
+func Test_ConcatPublicWitnesses(t *testing.T) {
+	var ws []witness.Witness
+	for _, assignment := range []*testutil.SquareCircuit{{X: 2, Y: 4}, {X: 3, Y: 9}} {
+		w, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws = append(ws, w)
+	}
+
+	merged, err := ConcatPublicWitnesses(ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err := witnessToDecimalStrings(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(values, []string{"4", "9"}) {
+		t.Fatalf("expected the public inputs of both witnesses, got %v", values)
+	}
+
+	labeled, err := frontend.NewWitness(&labeledCircuit{Secret: 6, Arr: [2]frontend.Variable{2, 3}, Inner: struct {
+		A frontend.Variable `gnark:",public"`
+	}{A: 1}}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ConcatPublicWitnesses([]witness.Witness{ws[0], labeled})
+	if err == nil || !strings.Contains(err.Error(), "witness 1 has 3 public inputs") {
+		t.Fatalf("expected a public input count mismatch, got %v", err)
+	}
+}
+
+func Test_SplitWitness(t *testing.T) {
+	assignment := &labeledCircuit{Secret: 6, Arr: [2]frontend.Variable{2, 3}}
+	assignment.Inner.A = 1
+	full, err := frontend.NewWitness(assignment, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := frontend.NewSchema(ecc.BN254.ScalarField(), &labeledCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	public, private, err := SplitWitness(full, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicValues, err := witnessToDecimalStrings(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateValues, err := witnessToDecimalStrings(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(publicValues, []string{"1", "2", "3"}) || !reflect.DeepEqual(privateValues, []string{"6"}) {
+		t.Fatalf("unexpected split %v / %v", publicValues, privateValues)
+	}
+
+	dir := t.TempDir()
+	publicFn, privateFn := filepath.Join(dir, "public.wtns"), filepath.Join(dir, "private.wtns")
+	if err := WriteSplitWitness(full, s, publicFn, privateFn); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(privateFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != SensitiveFileMode {
+		t.Fatalf("expected the secret witness to be written with %v, got %v", SensitiveFileMode, info.Mode().Perm())
+	}
+	restored, err := ReadWitness(privateFn, ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, _ := witnessToDecimalStrings(restored); !reflect.DeepEqual(values, []string{"6"}) {
+		t.Fatalf("unexpected secret witness %v", values)
+	}
+	restored, err = ReadWitness(publicFn, ecc.BN254)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values, _ := witnessToDecimalStrings(restored); !reflect.DeepEqual(values, publicValues) {
+		t.Fatalf("unexpected public witness %v", values)
+	}
+
+	if _, _, err := SplitWitness(public, s); err == nil {
+		t.Fatal("expected a public only witness to be refused")
+	}
+	if _, _, err := SplitWitness(full, nil); err == nil {
+		t.Fatal("expected a nil schema to be refused")
+	}
+	other, err := frontend.NewSchema(ecc.BN254.ScalarField(), &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := SplitWitness(full, other); err == nil {
+		t.Fatal("expected a witness of another circuit to be refused")
+	}
+}
+
+func Test_ReadProof_DetectsTruncationAndTrailingBytes(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	data, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	truncated := filepath.Join(dir, "truncated.proof")
+	if err := os.WriteFile(truncated, data[:len(data)-1], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProof(truncated); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a truncation error, got %v", err)
+	}
+
+	trailing := filepath.Join(dir, "trailing.proof")
+	if err := os.WriteFile(trailing, append(data, 0), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProof(trailing); err == nil || !strings.Contains(err.Error(), "1 trailing bytes") {
+		t.Fatalf("expected a trailing bytes error, got %v", err)
+	}
+
+	// a forged commitment count must fail before anything is allocated for it
+	forged := append([]byte{}, data...)
+	copy(forged[len(forged)-bn254.SizeOfG1AffineCompressed-4:], []byte{0xff, 0xff, 0xff, 0xff})
+	if _, err := UnmarshalProof(forged); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a truncation error, got %v", err)
+	}
+}
+
+// endlessReader yields proof bytes and then never ends, like a misbehaving peer.
+type endlessReader struct {
+	data []byte
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return len(p), nil
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func Test_ReadProofFrom(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	data, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the reader stops after the proof, leaving the rest of the stream
+	stream := bytes.NewReader(append(append([]byte{}, data...), "next"...))
+	p, err := ReadProofFrom(stream, int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(p, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := io.ReadAll(stream); string(rest) != "next" {
+		t.Fatalf("expected the trailing stream to be left unread, got %q", rest)
+	}
+
+	headed := append(proofHeader(ecc.BN254), data...)
+	if _, err := ReadProofFrom(bytes.NewReader(headed), int64(len(headed))); err != nil {
+		t.Fatal(err)
+	}
+	bls := proofHeader(ecc.BLS12_381)
+	if _, err := ReadProofFrom(bytes.NewReader(append(bls, data...)), 1<<20); err == nil {
+		t.Fatal("expected a header for another curve to be rejected")
+	}
+
+	if _, err := ReadProofFrom(bytes.NewReader(data), int64(len(data))-1); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected the limit to be hit, got %v", err)
+	}
+	// a length prefix announcing a huge number of commitments must not make the reader run away
+	huge := append([]byte{}, data...)
+	copy(huge[len(huge)-bn254.SizeOfG1AffineCompressed-4:], []byte{0xff, 0xff, 0xff, 0xff})
+	if _, err := ReadProofFrom(&endlessReader{data: huge}, 1<<16); !errors.Is(err, ErrFileTooLarge) {
+		t.Fatalf("expected the limit to stop an endless reader, got %v", err)
+	}
+	if _, err := ReadProofFrom(bytes.NewReader(data[:len(data)-1]), 1<<20); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a truncation error, got %v", err)
+	}
+}
+
+type publicCommitCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *publicCommitCircuit) Define(api frontend.API) error {
+	commitment, err := api.(frontend.Committer).Commit(c.X, c.Y)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commitment, 0)
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+// genCommitProof proves publicCommitCircuit with X = 3 on BN254 with opts, returning the public witness.
+func genCommitProof(t testing.TB, opts ...backend.ProverOption) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return proof, vk, publicWitness
+}
+
+// recordingHash records the digests gnark derives the commitment public inputs from.
+type recordingHash struct {
+	hash.Hash
+	sums [][]byte
+}
+
+func (h *recordingHash) Sum(b []byte) []byte {
+	sum := h.Hash.Sum(b)
+	h.sums = append(h.sums, sum)
+	return sum
+}
+
+func Test_CommitmentPublicInputs(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := &recordingHash{Hash: sha3.NewLegacyKeccak256()}
+	if err := groth16.Verify(proof, vk, publicWitness, backend.WithVerifierHashToFieldFunction(h)); err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := CommitmentPublicInputs(proof, vk, publicWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || len(h.sums) != 1 {
+		t.Fatalf("expected one derived input, got %d (gnark derived %d)", len(values), len(h.sums))
+	}
+	var want fr.Element
+	want.SetBytes(h.sums[0])
+	if values[0].Cmp(want.BigInt(new(big.Int))) != 0 {
+		t.Fatalf("derived input %s does not match gnark's %s", values[0], want.BigInt(new(big.Int)))
+	}
+
+	// the calldata input array holds only the circuit public inputs
+	var buf bytes.Buffer
+	if err := WriteVerifyCalldataTo(&buf, proof, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	var calldata verifyCalldata
+	if err := json.Unmarshal(buf.Bytes(), &calldata); err != nil {
+		t.Fatal(err)
+	}
+	if len(calldata.Input) != 1 || calldata.Input[0] != "9" {
+		t.Fatalf("expected input [9], got %v", calldata.Input)
+	}
+	if n := PublicInputCount(vk); n != len(calldata.Input) {
+		t.Fatalf("expected PublicInputCount to exclude the commitment input, got %d", n)
+	}
+}
+
+func Test_WritePublicWitnessInJson_DropsSecrets(t *testing.T) {
+	const secret = 1234567891
+	fullWitness, err := frontend.NewWitness(&testutil.SquareCircuit{X: secret, Y: secret * secret}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WritePublicWitnessInJsonTo(&buf, fullWitness); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), fmt.Sprint(secret)+`"`) {
+		t.Fatalf("secret assignment leaked into %s", buf.String())
+	}
+	if want := fmt.Sprintf(`["%d"]`, secret*secret); buf.String() != want {
+		t.Fatalf("expected %s, got %s", want, buf.String())
+	}
+
+	pw, err := PublicOnly(fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pw.Vector().(fr.Vector).Len() != 1 {
+		t.Fatalf("expected only the public input, got %d elements", pw.Vector().(fr.Vector).Len())
+	}
+}
+
+func Test_ValidateProofSolidityString(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	valid, err := ProofToSolidityString(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateProofSolidityString(valid); err != nil {
+		t.Fatal(err)
+	}
+	hexValid, err := ProofToSolidityString(proof, WithNumberFormat(Hex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateProofSolidityString(hexValid); err != nil {
+		t.Fatal(err)
+	}
+
+	words := "[1,2,3,4,5,6,7,8]"
+	if err := ValidateProofSolidityString(words + "\n[1,2,3,4]\n[1,2]"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		s    string
+		want error
+	}{
+		{"[1,2,3,4,5,6,7]", ErrWordCount},
+		{words + "\n[1,2,3]\n[1,2]", ErrWordCount},
+		{words + "\n[]\n[1]", ErrWordCount},
+		{"[1,2,3,x,5,6,7,8]", ErrUnparseableWord},
+		{"[1,2,3,4,5,6,7,-8]", ErrWordOutOfRange},
+		{"[1,2,3,4,5,6,7," + fp.Modulus().String() + "]", ErrWordOutOfRange},
+	} {
+		if err := ValidateProofSolidityString(tc.s); !errors.Is(err, tc.want) {
+			t.Fatalf("%q: expected %v, got %v", tc.s, tc.want, err)
+		}
+	}
+	if err := ValidateProofSolidityString(words + "\n[]"); err == nil {
+		t.Fatal("expected two lines to be rejected")
+	}
+}
+
+func Test_NormalizeG2ForEVM(t *testing.T) {
+	// the G2 generator as the EIP-197 precompile takes it, imaginary parts first
+	want := []string{
+		"11559732032986387107991004021392285783925812861821192530917403151452391805634",
+		"10857046999023057135944570762232829481370756359578518086990519993285655852781",
+		"4082367875863433681332203403145435568316851327593401208105741076214120093531",
+		"8495653923123431417604973247489272438418190587263600148770280649306958101930",
+	}
+	_, _, _, g2 := bn254.Generators()
+	got := NormalizeG2ForEVM(&g2)
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Fatalf("word %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+	if back := g2FromEVM(got[:]); !back.Equal(&g2) {
+		t.Fatal("g2FromEVM does not invert NormalizeG2ForEVM")
+	}
+
+	var proof groth16_bn254.Proof
+	proof.Bs = g2
+	words, _, _, err := proofToSolidityWords(&proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range want {
+		if words[2+i].String() != want[i] {
+			t.Fatalf("calldata word %d: expected %s, got %s", 2+i, want[i], words[2+i])
+		}
+	}
+}
+
+// VerifyCalldataMatchesGnark checks the calldata written by WriteVerifyCalldataTo against an independent Go
+// port of the pairing check of the exported Verifier.sol, decoding every word the way the contract does,
+// and fails t if that verdict differs from groth16.Verify. It returns the verdict. Like the exporter, only
+// BN254 proofs with at most one commitment are supported.
+func VerifyCalldataMatchesGnark(t testing.TB, vk groth16.VerifyingKey, proof groth16.Proof, publicWitness witness.Witness) bool {
+	t.Helper()
+
+	gnarkAccepts := groth16.Verify(proof, vk, publicWitness, solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16)) == nil
+	accepts, reason := verifyCalldataLikeContract(t, vk, proof, publicWitness)
+	if accepts != gnarkAccepts {
+		t.Fatalf("calldata verdict %v (%s) does not match groth16.Verify verdict %v", accepts, reason, gnarkAccepts)
+	}
+	return accepts
+}
+
+func verifyCalldataLikeContract(t testing.TB, vk groth16.VerifyingKey, proof groth16.Proof, publicWitness witness.Witness) (bool, string) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := WriteVerifyCalldataTo(&buf, proof, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	var calldata verifyCalldata
+	if err := json.Unmarshal(buf.Bytes(), &calldata); err != nil {
+		t.Fatal(err)
+	}
+	_vk := vk.(*groth16_bn254.VerifyingKey)
+	if len(_vk.PublicAndCommitmentCommitted) > 1 {
+		t.Fatalf("unsupported verifying key with %d commitments", len(_vk.PublicAndCommitmentCommitted))
+	}
+
+	words := func(strs []string) []*big.Int {
+		values := make([]*big.Int, len(strs))
+		for i, s := range strs {
+			v, ok := parseBigInt(s)
+			if !ok {
+				t.Fatalf("invalid calldata word %q", s)
+			}
+			values[i] = v
+		}
+		return values
+	}
+	g1 := func(w []*big.Int) (bn254.G1Affine, bool) {
+		var p bn254.G1Affine
+		for _, v := range w {
+			if v.Cmp(fp.Modulus()) >= 0 {
+				return p, false
+			}
+		}
+		p.X.SetBigInt(w[0])
+		p.Y.SetBigInt(w[1])
+		return p, p.IsOnCurve()
+	}
+
+	proofWords := words(calldata.Proof)
+	if len(proofWords) != ProofWordCount {
+		return false, "wrong proof length"
+	}
+	a, okA := g1(proofWords[0:2])
+	c, okC := g1(proofWords[6:8])
+	// the EIP-197 encoding of Fp2 elements puts the imaginary part first
+	var b bn254.G2Affine
+	b.X.A1.SetBigInt(proofWords[2])
+	b.X.A0.SetBigInt(proofWords[3])
+	b.Y.A1.SetBigInt(proofWords[4])
+	b.Y.A0.SetBigInt(proofWords[5])
+	if !okA || !okC || !b.IsInSubGroup() {
+		return false, "proof point not on curve"
+	}
+
+	input := words(calldata.Input)
+	for _, v := range input {
+		if v.Cmp(fr.Modulus()) >= 0 {
+			return false, "public input out of range"
+		}
+	}
+	var nbCommitments int
+	if len(_vk.PublicAndCommitmentCommitted) == 1 {
+		nbCommitments = 1
+	}
+	if len(_vk.G1.K) != 1+len(input)+nbCommitments {
+		return false, "wrong number of public inputs"
+	}
+
+	var l bn254.G1Jac
+	l.FromAffine(&_vk.G1.K[0])
+	addScaled := func(k *bn254.G1Affine, s *big.Int) {
+		var term bn254.G1Affine
+		term.ScalarMultiplication(k, s)
+		l.AddMixed(&term)
+	}
+	for i, v := range input {
+		addScaled(&_vk.G1.K[1+i], v)
+	}
+
+	if nbCommitments == 1 {
+		if len(calldata.Commitments) != 2 || len(calldata.CommitmentPok) != 2 {
+			return false, "missing commitment"
+		}
+		commitment, ok := g1(words(calldata.Commitments))
+		if !ok {
+			return false, "commitment not on curve"
+		}
+		pok, ok := g1(words(calldata.CommitmentPok))
+		if !ok {
+			return false, "commitment proof of knowledge not on curve"
+		}
+
+		// publicCommitments[0] = keccak256(commitment || committed inputs) mod r
+		h := sha3.NewLegacyKeccak256()
+		for _, s := range calldata.Commitments {
+			v, _ := parseBigInt(s)
+			h.Write(v.FillBytes(make([]byte, 32)))
+		}
+		for _, idx := range _vk.PublicAndCommitmentCommitted[0] {
+			h.Write(input[idx-1].FillBytes(make([]byte, 32)))
+		}
+		publicCommitment := new(big.Int).SetBytes(h.Sum(nil))
+		publicCommitment.Mod(publicCommitment, fr.Modulus())
+		addScaled(&_vk.G1.K[1+len(input)], publicCommitment)
+		l.AddMixed(&commitment)
+
+		key := _vk.CommitmentKeys[0]
+		ok, err := bn254.PairingCheck([]bn254.G1Affine{commitment, pok}, []bn254.G2Affine{key.GSigmaNeg, key.G})
+		if err != nil || !ok {
+			return false, "commitment proof of knowledge rejected"
+		}
+	}
+
+	var lAffine bn254.G1Affine
+	lAffine.FromJacobian(&l)
+	var betaNeg, gammaNeg, deltaNeg bn254.G2Affine
+	betaNeg.Neg(&_vk.G2.Beta)
+	gammaNeg.Neg(&_vk.G2.Gamma)
+	deltaNeg.Neg(&_vk.G2.Delta)
+	ok, err := bn254.PairingCheck(
+		[]bn254.G1Affine{a, c, _vk.G1.Alpha, lAffine},
+		[]bn254.G2Affine{b, deltaNeg, betaNeg, gammaNeg},
+	)
+	if err != nil || !ok {
+		return false, "pairing check failed"
+	}
+	return true, ""
+}
+
+func Test_VerifyCalldataMatchesGnark(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	if !VerifyCalldataMatchesGnark(t, vk, proof, publicWitness) {
+		t.Fatal("expected a valid proof to be accepted")
+	}
+
+	tampered := *proof.(*groth16_bn254.Proof)
+	tampered.Krs = tampered.Ar
+	if VerifyCalldataMatchesGnark(t, vk, &tampered, publicWitness) {
+		t.Fatal("expected a tampered proof to be rejected")
+	}
+
+	wrongWitness, err := frontend.NewWitness(&testutil.SquareCircuit{Y: 10}, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyCalldataMatchesGnark(t, vk, proof, wrongWitness) {
+		t.Fatal("expected a wrong public input to be rejected")
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vkCommit, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicCommit, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := groth16.Prove(ccs, pk, fullWitness, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyCalldataMatchesGnark(t, vkCommit, committed, publicCommit) {
+		t.Fatal("expected a valid proof with a commitment to be accepted")
+	}
+	// the contract hashes with keccak256, so a proof committed with gnark's default hash is rejected by both
+	defaultHash, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyCalldataMatchesGnark(t, vkCommit, defaultHash, publicCommit) {
+		t.Fatal("expected a proof committed with another hash to be rejected")
+	}
+}
+
+func Test_ReadErrorsNameFullPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "artifacts")
+	fn := filepath.Join(dir, "garbage")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(fn, []byte("not an artifact"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "missing")
+
+	for _, name := range []string{fn, missing} {
+		readers := map[string]func() error{
+			"ReadCcs":            func() error { _, err := ReadCcs(name); return err },
+			"ReadCcsLimited":     func() error { _, err := ReadCcsLimited(name, 1<<20); return err },
+			"ReadVkWithCurve":    func() error { _, err := ReadVkWithCurve(name, ecc.BN254); return err },
+			"ReadProofWithCurve": func() error { _, err := ReadProofWithCurve(name, ecc.BN254); return err },
+		}
+		for reader, read := range readers {
+			err := read()
+			if err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("%s: expected an error naming %s, got %v", reader, name, err)
+			}
+		}
+	}
 }
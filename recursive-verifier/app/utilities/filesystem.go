@@ -0,0 +1,401 @@
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Filesystem is the set of file operations the package is built on. Every helper goes through
+// DefaultFilesystem, so replacing it, e.g. with a MemFilesystem, redirects all reads and writes.
+type Filesystem interface {
+	// Open opens name for reading.
+	Open(name string) (fs.File, error)
+	// Create creates or truncates name with the given permissions. The parent directory must exist.
+	Create(name string, perm fs.FileMode) (WritableFile, error)
+	// CreateExclusive creates name with the given permissions, failing with fs.ErrExist if anything already
+	// exists at name, a symlink included. Temporary files are created with it.
+	CreateExclusive(name string, perm fs.FileMode) (WritableFile, error)
+	Stat(name string) (fs.FileInfo, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	Rename(oldpath, newpath string) error
+}
+
+// WritableFile is a file opened for writing by a Filesystem.
+type WritableFile interface {
+	io.Writer
+	io.Closer
+	Sync() error
+}
+
+// appendFilesystem is implemented by filesystems able to append to a file in place, as AppendProof needs.
+type appendFilesystem interface {
+	OpenAppend(name string, perm fs.FileMode) (WritableFile, error)
+}
+
+// DefaultFilesystem is the Filesystem used by every helper of the package. It defaults to the OS filesystem.
+var DefaultFilesystem Filesystem = OSFilesystem{}
+
+// OSFilesystem is the Filesystem backed by the os package.
+type OSFilesystem struct{}
+
+func (OSFilesystem) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+// Create sets perm explicitly after creating the file, so the umask does not apply.
+func (OSFilesystem) Create(name string, perm fs.FileMode) (WritableFile, error) {
+	return openWithPerm(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+// CreateExclusive opens name with O_EXCL, which never follows a symlink, like os.CreateTemp does.
+func (OSFilesystem) CreateExclusive(name string, perm fs.FileMode) (WritableFile, error) {
+	return openWithPerm(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, perm)
+}
+
+func openWithPerm(name string, flag int, perm fs.FileMode) (*os.File, error) {
+	f, err := os.OpenFile(name, flag, perm)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(perm); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (OSFilesystem) OpenAppend(name string, perm fs.FileMode) (WritableFile, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, perm)
+}
+
+func (OSFilesystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFilesystem) MkdirAll(path string, perm fs.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OSFilesystem) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFilesystem) Rename(oldpath, newpath string) error {
+	return os.Rename(oldpath, newpath)
+}
+
+// pathFS exposes DefaultFilesystem as an fs.FS opening operating system paths, so the path based readers can
+// share the fs.FS based ones and still name the full path in their errors.
+func pathFS() fs.FS {
+	return filesystemFS{fsys: DefaultFilesystem}
+}
+
+type filesystemFS struct {
+	fsys Filesystem
+}
+
+func (f filesystemFS) Open(name string) (fs.File, error) {
+	return f.fsys.Open(name)
+}
+
+// readFile reads name whole from DefaultFilesystem.
+func readFile(name string) ([]byte, error) {
+	f, err := DefaultFilesystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return io.ReadAll(f)
+}
+
+// createTemp creates a new file in dir named pattern with a random suffix, in the manner of os.CreateTemp.
+// The name is claimed with CreateExclusive, so nothing planted at it beforehand is ever opened.
+func createTemp(fsys Filesystem, dir, pattern string, perm fs.FileMode) (WritableFile, string, error) {
+	for try := 0; try < 100; try++ {
+		name := filepath.Join(dir, pattern+strconv.FormatUint(uint64(rand.Uint32()), 10))
+		f, err := fsys.CreateExclusive(name, perm)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		return f, name, nil
+	}
+	return nil, "", &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, pattern+"*"), Err: fs.ErrExist}
+}
+
+// MemFilesystem is an in-memory Filesystem, for tests and for environments without a writable disk.
+// Paths are cleaned with filepath.Clean, "." and "/" always exist. It is safe for concurrent use.
+type MemFilesystem struct {
+	mu    sync.Mutex
+	files map[string]*memEntry
+	dirs  map[string]fs.FileMode
+}
+
+type memEntry struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// NewMemFilesystem returns an empty MemFilesystem.
+func NewMemFilesystem() *MemFilesystem {
+	return &MemFilesystem{
+		files: make(map[string]*memEntry),
+		dirs:  make(map[string]fs.FileMode),
+	}
+}
+
+// Files returns the names of all files held, sorted.
+func (m *MemFilesystem) Files() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *MemFilesystem) isDir(name string) bool {
+	if name == "." || name == string(filepath.Separator) {
+		return true
+	}
+	_, ok := m.dirs[name]
+	return ok
+}
+
+func (m *MemFilesystem) Open(name string) (fs.File, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.files[name]; ok {
+		info := memFileInfo{name: filepath.Base(name), size: int64(len(e.data)), mode: e.mode, modTime: e.modTime}
+		return &memReadFile{Reader: bytes.NewReader(e.data), info: info}, nil
+	}
+	if m.isDir(name) {
+		return &memReadFile{Reader: bytes.NewReader(nil), info: m.dirInfo(name)}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *MemFilesystem) Create(name string, perm fs.FileMode) (WritableFile, error) {
+	return m.open(name, perm, true, false)
+}
+
+func (m *MemFilesystem) CreateExclusive(name string, perm fs.FileMode) (WritableFile, error) {
+	return m.open(name, perm, true, true)
+}
+
+func (m *MemFilesystem) OpenAppend(name string, perm fs.FileMode) (WritableFile, error) {
+	return m.open(name, perm, false, false)
+}
+
+func (m *MemFilesystem) open(name string, perm fs.FileMode, truncate, exclusive bool) (WritableFile, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.isDir(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	}
+	if !m.isDir(filepath.Dir(name)) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	e, ok := m.files[name]
+	if ok && exclusive {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	if !ok || truncate {
+		e = &memEntry{mode: perm.Perm(), modTime: time.Now()}
+		m.files[name] = e
+	}
+	return &memWriteFile{fs: m, entry: e}, nil
+}
+
+func (m *MemFilesystem) Stat(name string) (fs.FileInfo, error) {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.files[name]; ok {
+		return memFileInfo{name: filepath.Base(name), size: int64(len(e.data)), mode: e.mode, modTime: e.modTime}, nil
+	}
+	if m.isDir(name) {
+		return m.dirInfo(name), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (m *MemFilesystem) dirInfo(name string) memFileInfo {
+	perm, ok := m.dirs[name]
+	if !ok {
+		perm = 0o755
+	}
+	return memFileInfo{name: filepath.Base(name), mode: fs.ModeDir | perm}
+}
+
+func (m *MemFilesystem) MkdirAll(path string, perm fs.FileMode) error {
+	path = filepath.Clean(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var missing []string
+	for dir := path; !m.isDir(dir); dir = filepath.Dir(dir) {
+		if _, ok := m.files[dir]; ok {
+			return &fs.PathError{Op: "mkdir", Path: dir, Err: syscall.ENOTDIR}
+		}
+		missing = append(missing, dir)
+	}
+	for _, dir := range missing {
+		m.dirs[dir] = perm.Perm()
+	}
+	return nil
+}
+
+func (m *MemFilesystem) Remove(name string) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.files[name]; ok {
+		delete(m.files, name)
+		return nil
+	}
+	if _, ok := m.dirs[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if m.hasChildren(name) {
+		return &fs.PathError{Op: "remove", Path: name, Err: syscall.ENOTEMPTY}
+	}
+	delete(m.dirs, name)
+	return nil
+}
+
+func (m *MemFilesystem) hasChildren(dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	for name := range m.files {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	for name := range m.dirs {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rename moves a file, replacing newpath if it is a file. Renaming directories is not supported.
+func (m *MemFilesystem) Rename(oldpath, newpath string) error {
+	oldpath, newpath = filepath.Clean(oldpath), filepath.Clean(newpath)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.files[oldpath]
+	if !ok {
+		err := fs.ErrNotExist
+		if m.isDir(oldpath) {
+			err = fs.ErrInvalid
+		}
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: err}
+	}
+	if m.isDir(newpath) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EISDIR}
+	}
+	if !m.isDir(filepath.Dir(newpath)) {
+		return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: fs.ErrNotExist}
+	}
+
+	delete(m.files, oldpath)
+	m.files[newpath] = e
+	return nil
+}
+
+type memReadFile struct {
+	*bytes.Reader
+	info memFileInfo
+}
+
+func (f *memReadFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+func (f *memReadFile) Close() error {
+	return nil
+}
+
+// memWriteFile writes through to its entry, which stays attached across renames like an open file on disk.
+type memWriteFile struct {
+	fs     *MemFilesystem
+	entry  *memEntry
+	closed bool
+}
+
+func (f *memWriteFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, fmt.Errorf("write: %w", fs.ErrClosed)
+	}
+	f.entry.data = append(f.entry.data, p...)
+	f.entry.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memWriteFile) Sync() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("sync: %w", fs.ErrClosed)
+	}
+	return nil
+}
+
+func (f *memWriteFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return fmt.Errorf("close: %w", fs.ErrClosed)
+	}
+	f.closed = true
+	return nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i memFileInfo) ModTime() time.Time { return i.modTime }
+func (i memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i memFileInfo) Sys() any           { return nil }
@@ -0,0 +1,160 @@
+package utilities
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+func Test_MemFilesystem(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	mem := NewMemFilesystem()
+	DefaultFilesystem = mem
+	defer func() { DefaultFilesystem = OSFilesystem{} }()
+
+	dir := filepath.Join(t.TempDir(), "out")
+	proofFn := filepath.Join(dir, "proof")
+	vkFn := filepath.Join(dir, "vk")
+	batchFn := filepath.Join(dir, "proofs.batch")
+	if err := WriteProof(proof, proofFn, WithVerifyAfterWrite()); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVk(vk, vkFn); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := AppendProof(proof, batchFn); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected nothing to be written to disk, got %v", err)
+	}
+	if got, want := mem.Files(), []string{proofFn, batchFn, vkFn}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected files %v, got %v", want, got)
+	}
+	if exists, err := FileExists(proofFn); err != nil || !exists {
+		t.Fatalf("expected %s to exist, got %v (%v)", proofFn, exists, err)
+	}
+
+	readProof, err := ReadProof(proofFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readVk, err := ReadVk(vkFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(readProof, readVk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	proofs, err := ReadProofBatch(batchFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proofs) != 2 {
+		t.Fatalf("expected 2 appended proofs, got %d", len(proofs))
+	}
+	if _, err := ReadProof(filepath.Join(dir, "missing")); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected a missing file error, got %v", err)
+	}
+}
+
+func Test_MemFilesystem_Operations(t *testing.T) {
+	mem := NewMemFilesystem()
+
+	if _, err := mem.Create("a/b", 0o644); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected creating a file in a missing directory to fail, got %v", err)
+	}
+	if err := mem.MkdirAll("a", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := mem.Create("a/b", 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("more")); !errors.Is(err, fs.ErrClosed) {
+		t.Fatalf("expected writing a closed file to fail, got %v", err)
+	}
+
+	info, err := mem.Stat("a/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 4 || info.Mode() != 0o600 || info.IsDir() {
+		t.Fatalf("unexpected file info %d %v", info.Size(), info.Mode())
+	}
+	if err := mem.MkdirAll("a/b/c", 0o755); err == nil {
+		t.Fatal("expected creating a directory below a file to fail")
+	}
+
+	if err := mem.Rename("a/b", "a/c"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.Stat("a/b"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected the old name to be gone, got %v", err)
+	}
+	if err := mem.Remove("a"); err == nil {
+		t.Fatal("expected removing a non-empty directory to fail")
+	}
+	if err := mem.Remove("a/c"); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.Remove("a"); err != nil {
+		t.Fatal(err)
+	}
+	if files := mem.Files(); len(files) != 0 {
+		t.Fatalf("expected no files left, got %v", files)
+	}
+}
+
+func Test_OSFilesystem_CreateExclusive(t *testing.T) {
+	dir := t.TempDir()
+	victim := filepath.Join(dir, "victim")
+	if err := os.WriteFile(victim, []byte("victim"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, target := range []string{victim, filepath.Join(dir, "missing")} {
+		link := filepath.Join(dir, "link-"+filepath.Base(target))
+		if err := os.Symlink(target, link); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := (OSFilesystem{}).CreateExclusive(link, 0o600); !errors.Is(err, fs.ErrExist) {
+			t.Fatalf("expected creating over a symlink to %s to fail with fs.ErrExist, got %v", target, err)
+		}
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "victim" {
+		t.Fatalf("expected the symlink target to be untouched, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("expected a dangling symlink not to be followed, got %v", err)
+	}
+
+	f, err := (OSFilesystem{}).CreateExclusive(filepath.Join(dir, "new"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	mem := NewMemFilesystem()
+	if _, err := mem.CreateExclusive("new", 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mem.CreateExclusive("new", 0o600); !errors.Is(err, fs.ErrExist) {
+		t.Fatalf("expected an existing in-memory file to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"text/template"
+
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// ForgeScriptContract is the name of the script contract written by WriteForgeScript.
+const ForgeScriptContract = "VerifyProofScript"
+
+var forgeScriptTemplate = template.Must(template.New("forge").Parse(`// SPDX-License-Identifier: MIT
+pragma solidity {{.Pragma}};
+
+import {Script} from "forge-std/Script.sol";
+import { {{.Verifier}} } from "./{{.VerifierFile}}";
+
+contract {{.Script}} is Script {
+    function run() external {
+        {{.Verifier}} verifier = new {{.Verifier}}();
+        uint256[8] memory proof = {{.Proof}};
+{{- if .Commitments}}
+        uint256[{{.NbCommitmentWords}}] memory commitments = {{.Commitments}};
+        uint256[2] memory commitmentPok = {{.CommitmentPok}};
+{{- end}}
+        uint256[{{.NbInputs}}] memory input = {{.Input}};
+        verifier.verifyProof(proof{{if .Commitments}}, commitments, commitmentPok{{end}}, input);
+    }
+}
+`))
+
+// WriteForgeScript writes a Foundry script to fn, conventionally named *.s.sol, deploying the verifier
+// contract exported for vk and calling its verifyProof function with proof and the public inputs of pw
+// inlined, so that `forge script` reverts unless the proof verifies: like the contract, a commitment proof
+// must be generated with solidity.WithProverTargetSolidityVerifier to pass. The contract is imported from
+// Verifier.sol next to the script, as WriteSolidityBundle names it. vk only sizes the arguments, it is
+// checked to take as many inputs and commitments as proof and pw hold. WithContractName imports a verifier
+// renamed by WriteVkInSolidityWithOptions from <name>.sol instead, WithPragma sets the version constraint
+// of the script, ^0.8.0 by default, and WithNumberFormat the format of the inlined words.
+func WriteForgeScript(vk groth16.VerifyingKey, proof groth16.Proof, pw witness.Witness, fn string, opts ...SolidityOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteForgeScriptTo(w, vk, proof, pw, opts...)
+	})
+}
+
+// WriteForgeScriptTo streams the Foundry script of WriteForgeScript to w.
+func WriteForgeScriptTo(w io.Writer, vk groth16.VerifyingKey, proof groth16.Proof, pw witness.Witness, opts ...SolidityOption) error {
+	cfg := newSolidityConfig(opts)
+
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("unsupported verifying key type %T, only BN254 is supported", vk)
+	}
+	if err := CheckPublicInputCount(vk, pw); err != nil {
+		return err
+	}
+	proofInSol, commitmentsInSol, commitmentPokInSol, err := proofToSolidityWords(proof)
+	if err != nil {
+		return err
+	}
+	if nbCommitments := len(commitmentsInSol) / WordsPerCommitment; nbCommitments != len(_vk.PublicAndCommitmentCommitted) {
+		return fmt.Errorf("proof holds %d commitments, verifying key expects %d", nbCommitments, len(_vk.PublicAndCommitmentCommitted))
+	}
+	publicWitness, err := PublicOnly(pw)
+	if err != nil {
+		return err
+	}
+	input, err := witnessToBigInts(publicWitness)
+	if err != nil {
+		return err
+	}
+	if len(input) == 0 {
+		return errors.New("the verifier takes no public inputs, Solidity has no uint256[0] argument to call it with")
+	}
+
+	verifier := "Verifier"
+	if cfg.contractName != "" {
+		if !identifierPattern.MatchString(cfg.contractName) {
+			return fmt.Errorf("invalid contract name %q", cfg.contractName)
+		}
+		verifier = cfg.contractName
+	}
+	pragma := "^0.8.0"
+	if cfg.pragma != "" {
+		if !pragmaVersionPattern.MatchString(cfg.pragma) {
+			return fmt.Errorf("invalid pragma version %q", cfg.pragma)
+		}
+		pragma = cfg.pragma
+	}
+
+	data := struct {
+		Pragma, Verifier, VerifierFile, Script string
+		Proof, Commitments, CommitmentPok      string
+		NbCommitmentWords, NbInputs            int
+		Input                                  string
+	}{
+		Pragma:       pragma,
+		Verifier:     verifier,
+		VerifierFile: verifier + ".sol",
+		Script:       ForgeScriptContract,
+		Proof:        solidityUintArray(proofInSol[:], cfg.format),
+		NbInputs:     len(input),
+		Input:        solidityUintArray(input, cfg.format),
+	}
+	if len(commitmentsInSol) > 0 {
+		data.Commitments = solidityUintArray(commitmentsInSol, cfg.format)
+		data.CommitmentPok = solidityUintArray(commitmentPokInSol[:], cfg.format)
+		data.NbCommitmentWords = len(commitmentsInSol)
+	}
+	return forgeScriptTemplate.Execute(w, data)
+}
+
+// solidityUintArray formats words as a Solidity uint256 array literal. Every element is cast, an array literal
+// otherwise takes the smallest type holding its first element, e.g. uint8 for a small public input.
+func solidityUintArray(words []*big.Int, format NumberFormat) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, v := range words {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString("uint256(" + formatBigInt(v, format) + ")")
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,90 @@
+package utilities
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+var (
+	verifyProofCall = regexp.MustCompile(`verifier\.verifyProof\(([^)]*)\);`)
+	verifyProofDecl = regexp.MustCompile(`function verifyProof\(([^)]*)\)`)
+)
+
+func Test_WriteForgeScript(t *testing.T) {
+	plain, plainVk, plainWitness := genTestProof(t)
+
+	// the script runs the proof through the contract, which derives commitment inputs with keccak256
+	committed, committedVk, committedWitness := genCommitProof(t, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+
+	for _, tc := range []struct {
+		name  string
+		proof groth16.Proof
+		vk    groth16.VerifyingKey
+		pw    witness.Witness
+	}{
+		{"plain", plain, plainVk, plainWitness},
+		{"committed", committed, committedVk, committedWitness},
+	} {
+		dir := t.TempDir()
+		script := filepath.Join(dir, "Verify.s.sol")
+		if err := WriteForgeScript(tc.vk, tc.proof, tc.pw, script, WithNumberFormat(Hex)); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(script)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var verifier strings.Builder
+		if err := WriteVkInSolidityTo(&verifier, tc.vk); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "Verifier.sol"), []byte(verifier.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		call := verifyProofCall.FindSubmatch(data)
+		decl := verifyProofDecl.FindStringSubmatch(verifier.String())
+		if call == nil || decl == nil || len(strings.Split(string(call[1]), ",")) != len(strings.Split(decl[1], ",")) {
+			t.Fatalf("%s: expected the verifyProof call to match the exported verifier:\n%s", tc.name, data)
+		}
+		if !strings.Contains(string(data), "uint256[1] memory input = [uint256(0x0000000000000000000000000000000000000000000000000000000000000009)];") {
+			t.Fatalf("%s: expected the public input inlined:\n%s", tc.name, data)
+		}
+		t.Run(tc.name+"/compile", func(t *testing.T) {
+			compileForgeScript(t, script)
+		})
+	}
+
+	if err := WriteForgeScript(committedVk, plain, plainWitness, filepath.Join(t.TempDir(), "Verify.s.sol")); err == nil {
+		t.Fatal("expected a proof without the commitments of the key to be refused")
+	}
+}
+
+// compileForgeScript compiles script against a stub of forge-std, skipping t when solc is not installed.
+func compileForgeScript(t *testing.T, script string) {
+	t.Helper()
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Skip("solc not found, the forge script is not compiled")
+	}
+	stub := filepath.Join(filepath.Dir(script), "forge-std")
+	if err := os.MkdirAll(stub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(stub, "Script.sol"), []byte("// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\nabstract contract Script {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(solc, "forge-std/="+stub+"/", "--base-path", filepath.Dir(script), "--bin", script)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("solc failed to compile the forge script: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,61 @@
+package utilities
+
+import (
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// Gas costs of the operations of the verifier contract, as priced since the Istanbul hard fork.
+const (
+	gasTransaction    = 21000 // intrinsic cost of a transaction, Yellow Paper G_transaction
+	gasCalldataByte   = 16    // non-zero calldata byte, EIP-2028
+	gasEcAdd          = 150   // ECADD precompile 0x06, EIP-1108
+	gasEcMul          = 6000  // ECMUL precompile 0x07, EIP-1108
+	gasPairingBase    = 45000 // ECPAIRING precompile 0x08, EIP-1108: 45000 + 34000 per pair
+	gasPairingPerPair = 34000
+	gasKeccakBase     = 30 // KECCAK256, Yellow Paper G_keccak256 + G_keccak256word per word
+	gasKeccakWord     = 6
+
+	// gasVerifierOverhead is a rough allowance for everything else verifyProof executes: dispatch, calldata
+	// loads, field range checks, memory expansion and the staticcalls themselves.
+	gasVerifierOverhead = 10000
+)
+
+// EstimateVerifyGas returns a planning estimate of the gas a transaction calling verifyProof on the contract
+// written by WriteVkInSolidity for vk costs, or 0 for keys of other curves than BN254. The model follows the
+// structure of gnark's verifier:
+//   - the intrinsic transaction cost and the calldata of the call, counted as if every byte was non-zero;
+//   - an ECMUL and an ECADD per public input, derived inputs included, for the MSM with the K points of vk;
+//   - the 4 pair pairing check of Groth16;
+//   - per commitment, a keccak256 of the commitment and its committed inputs and the ECADD folding it into
+//     the MSM, plus, once, the 2 pair pairing check of the proof of knowledge of the commitments;
+//   - a fixed allowance for the rest of the execution.
+//
+// Precompile costs dominate and are exact, the rest is approximate: expect the actual cost within a few
+// thousand gas, measure it on a testnet before relying on a tight budget.
+func EstimateVerifyGas(vk groth16.VerifyingKey) uint64 {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return 0
+	}
+	nbCommitments := uint64(len(_vk.PublicAndCommitmentCommitted))
+	nbPublic := uint64(len(_vk.G1.K) - 1) // the constant one wire is K[0], added without ECMUL
+
+	// selector, then proof, commitments and their proof of knowledge, and inputs as 32 byte words
+	calldataWords := uint64(ProofWordCount) + nbPublic - nbCommitments
+	if nbCommitments > 0 {
+		calldataWords += WordsPerCommitment*nbCommitments + 2
+	}
+	gas := uint64(gasTransaction) + gasCalldataByte*(4+32*calldataWords)
+
+	gas += nbPublic * (gasEcMul + gasEcAdd)
+	gas += gasPairingBase + 4*gasPairingPerPair
+	if nbCommitments > 0 {
+		for _, committed := range _vk.PublicAndCommitmentCommitted {
+			gas += gasKeccakBase + gasKeccakWord*uint64(WordsPerCommitment+len(committed))
+			gas += gasEcAdd
+		}
+		gas += gasPairingBase + 2*gasPairingPerPair
+	}
+	return gas + gasVerifierOverhead
+}
@@ -0,0 +1,38 @@
+package utilities
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+func Test_EstimateVerifyGas(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	// one input: transaction and 9 words of calldata, one ECMUL and ECADD, the 4 pair pairing check
+	want := uint64(21000 + 16*(4+32*9) + 6150 + 45000 + 4*34000 + gasVerifierOverhead)
+	if got := EstimateVerifyGas(vk); got != want {
+		t.Fatalf("expected %d gas, got %d", want, got)
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, commitVk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the derived input, the commitment hash and fold, the proof of knowledge pairing and 4 more words
+	extra := uint64(6150 + 30 + 6*3 + 150 + 45000 + 2*34000 + 16*32*4)
+	if got := EstimateVerifyGas(commitVk); got != want+extra {
+		t.Fatalf("expected %d gas with a commitment, got %d", want+extra, got)
+	}
+
+	_, blsVk, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if got := EstimateVerifyGas(blsVk); got != 0 {
+		t.Fatalf("expected 0 for a BLS12-381 key, got %d", got)
+	}
+}
@@ -0,0 +1,14 @@
+package utilities
+
+import "log/slog"
+
+// Logger receives the debug records of the file operations of the package: directories created, files
+// written or replaced, temporary files removed and artifacts read. Records are only emitted at slog.LevelDebug,
+// at most a few per file. A nil Logger, the default, logs nothing.
+var Logger *slog.Logger
+
+func logDebug(msg string, args ...any) {
+	if Logger != nil {
+		Logger.Debug(msg, args...)
+	}
+}
@@ -0,0 +1,58 @@
+package utilities
+
+import (
+	"bytes"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_Logger(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	fn := filepath.Join(t.TempDir(), "nested", "proof")
+
+	// nothing is logged without a Logger
+	if err := WriteProof(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	defer func() { Logger = nil }()
+
+	if err := WriteProof(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadProof(fn); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{`msg="wrote file"`, "replaced=true", `msg="read proof"`} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %s in the log:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "created directory") {
+		t.Fatalf("expected the existing directory not to be reported:\n%s", out)
+	}
+
+	buf.Reset()
+	other := filepath.Join(filepath.Dir(fn), "new", "proof")
+	if err := WriteProof(proof, other); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, `msg="created directory"`) || !strings.Contains(out, "replaced=false") {
+		t.Fatalf("expected the new directory and file to be reported:\n%s", out)
+	}
+
+	// records are only emitted at debug level
+	buf.Reset()
+	Logger = slog.New(slog.NewTextHandler(&buf, nil))
+	if err := WriteProof(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("expected no records above debug level, got:\n%s", buf.String())
+	}
+}
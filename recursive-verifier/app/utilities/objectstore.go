@@ -0,0 +1,155 @@
+package utilities
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ObjectStore is the minimal client surface of a bucket, such as S3 or GCS, needed by ObjectStoreFilesystem.
+// Implementations wrap the vendor SDK and must report missing keys with an error matching fs.ErrNotExist.
+type ObjectStore interface {
+	// Get opens the object at key for reading.
+	Get(key string) (io.ReadCloser, error)
+	// Put stores everything read from r at key, replacing any previous object. The object must only become
+	// visible once r is exhausted, and not at all if r fails, as single PUT and multipart uploads behave.
+	Put(key string, r io.Reader) error
+	// Head returns the size and modification time of the object at key.
+	Head(key string) (size int64, modTime time.Time, err error)
+	// Copy duplicates the object at src to dst server-side.
+	Copy(src, dst string) error
+	Delete(key string) error
+}
+
+// ObjectStoreFilesystem adapts an ObjectStore to the Filesystem interface, so setting it as DefaultFilesystem
+// routes every Write* and Read* helper to a bucket. File names map to keys by cleaning them, converting
+// separators to slashes and dropping any leading slash, e.g. /artifacts/proof becomes artifacts/proof.
+//
+// Object stores cannot give every guarantee of a local filesystem:
+//   - Rename is a Copy followed by a Delete. The target is still replaced all at once, since every
+//     object is written by a single Put, but the temporary object stays visible until the Delete and is
+//     left behind if the Delete fails.
+//   - Concurrent writers of the same key are not serialized, the last completed Put wins, and
+//     CreateExclusive only checks that the key is free before its Put.
+//   - There are no directories: MkdirAll is a no-op, prefixes never Stat as directories and permissions
+//     passed to Create and MkdirAll are ignored, as are the modes reported by Stat.
+//   - Sync is a no-op, the object is durable once Close returns without error.
+//   - Appending is not supported, so AppendProof fails.
+type ObjectStoreFilesystem struct {
+	store ObjectStore
+}
+
+// NewObjectStoreFilesystem returns a Filesystem storing files as objects of store.
+func NewObjectStoreFilesystem(store ObjectStore) *ObjectStoreFilesystem {
+	return &ObjectStoreFilesystem{store: store}
+}
+
+func objectKey(name string) string {
+	return strings.TrimPrefix(path.Clean(filepath.ToSlash(name)), "/")
+}
+
+func (o *ObjectStoreFilesystem) Open(name string) (fs.File, error) {
+	info, err := o.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := o.store.Get(objectKey(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &objectFile{ReadCloser: rc, info: info}, nil
+}
+
+// Create streams the written bytes into a Put running alongside the writer, Close waits for it to complete.
+func (o *ObjectStoreFilesystem) Create(name string, _ fs.FileMode) (WritableFile, error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := o.store.Put(objectKey(name), pr)
+		// unblock and fail pending writes if the upload gave up early
+		_ = pr.CloseWithError(err)
+		done <- err
+	}()
+	return &objectWriter{name: name, pw: pw, done: done}, nil
+}
+
+// CreateExclusive checks that no object exists at name before creating it. Object stores have no exclusive
+// Put, so two concurrent calls may both succeed.
+func (o *ObjectStoreFilesystem) CreateExclusive(name string, perm fs.FileMode) (WritableFile, error) {
+	if _, err := o.Stat(name); err == nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	}
+	return o.Create(name, perm)
+}
+
+func (o *ObjectStoreFilesystem) Stat(name string) (fs.FileInfo, error) {
+	size, modTime, err := o.store.Head(objectKey(name))
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return memFileInfo{name: path.Base(objectKey(name)), size: size, mode: 0o644, modTime: modTime}, nil
+}
+
+func (o *ObjectStoreFilesystem) MkdirAll(string, fs.FileMode) error {
+	return nil
+}
+
+func (o *ObjectStoreFilesystem) Remove(name string) error {
+	if err := o.store.Delete(objectKey(name)); err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return nil
+}
+
+func (o *ObjectStoreFilesystem) Rename(oldpath, newpath string) error {
+	if err := o.store.Copy(objectKey(oldpath), objectKey(newpath)); err != nil {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: err}
+	}
+	if err := o.store.Delete(objectKey(oldpath)); err != nil {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: err}
+	}
+	return nil
+}
+
+type objectFile struct {
+	io.ReadCloser
+	info fs.FileInfo
+}
+
+func (f *objectFile) Stat() (fs.FileInfo, error) {
+	return f.info, nil
+}
+
+type objectWriter struct {
+	name   string
+	pw     *io.PipeWriter
+	done   chan error
+	closed bool
+}
+
+func (w *objectWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, &fs.PathError{Op: "write", Path: w.name, Err: fs.ErrClosed}
+	}
+	return w.pw.Write(p)
+}
+
+func (w *objectWriter) Sync() error {
+	return nil
+}
+
+func (w *objectWriter) Close() error {
+	if w.closed {
+		return &fs.PathError{Op: "close", Path: w.name, Err: fs.ErrClosed}
+	}
+	w.closed = true
+
+	_ = w.pw.Close()
+	if err := <-w.done; err != nil {
+		return &fs.PathError{Op: "close", Path: w.name, Err: err}
+	}
+	return nil
+}
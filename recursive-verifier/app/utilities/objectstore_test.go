@@ -0,0 +1,138 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// fakeObjectStore is an in-memory bucket whose Put, like a real one, only publishes complete uploads.
+type fakeObjectStore struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	copies  int
+}
+
+func (s *fakeObjectStore) Get(key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *fakeObjectStore) Put(key string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = data
+	return nil
+}
+
+func (s *fakeObjectStore) Head(key string) (int64, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[key]
+	if !ok {
+		return 0, time.Time{}, fs.ErrNotExist
+	}
+	return int64(len(data)), time.Time{}, nil
+}
+
+func (s *fakeObjectStore) Copy(src, dst string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.objects[src]
+	if !ok {
+		return fs.ErrNotExist
+	}
+	s.objects[dst] = data
+	s.copies++
+	return nil
+}
+
+func (s *fakeObjectStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.objects[key]; !ok {
+		return fs.ErrNotExist
+	}
+	delete(s.objects, key)
+	return nil
+}
+
+func (s *fakeObjectStore) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for k := range s.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func Test_ObjectStoreFilesystem(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+
+	store := &fakeObjectStore{objects: make(map[string][]byte)}
+	DefaultFilesystem = NewObjectStoreFilesystem(store)
+	defer func() { DefaultFilesystem = OSFilesystem{} }()
+
+	if err := WriteProof(proof, "/artifacts/proof"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVk(vk, "artifacts/vk"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := store.keys(), []string{"artifacts/proof", "artifacts/vk"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected objects %v without temporaries, got %v", want, got)
+	}
+	if store.copies != 2 {
+		t.Fatalf("expected each write to be published by a copy, got %d copies", store.copies)
+	}
+
+	readProof, err := ReadProof("artifacts/proof")
+	if err != nil {
+		t.Fatal(err)
+	}
+	readVk, err := ReadVk("/artifacts/vk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := groth16.Verify(readProof, readVk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed write leaves the published object alone
+	err = WriteAtomic("artifacts/proof", func(w io.Writer) error {
+		_, _ = w.Write([]byte("partial"))
+		return fmt.Errorf("interrupted")
+	})
+	if err == nil {
+		t.Fatal("expected the write to fail")
+	}
+	if _, err := ReadProof("artifacts/proof"); err != nil {
+		t.Fatalf("expected the original proof to be intact, got %v", err)
+	}
+	if got := store.keys(); len(got) != 2 {
+		t.Fatalf("expected the temporary object to be removed, got %v", got)
+	}
+
+	if err := AppendProof(proof, "artifacts/proofs.batch"); err == nil {
+		t.Fatal("expected appending to an object store to fail")
+	}
+}
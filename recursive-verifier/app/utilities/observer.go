@@ -0,0 +1,27 @@
+package utilities
+
+import "time"
+
+// Observer is notified after every successful write of the Write* helpers, e.g. to export metrics.
+// kind names the artifact: "proof", "vk", "pk", "ccs", or "file" for writes through WriteAtomic directly.
+// OnWrite may be called concurrently from several writes and must not block.
+type Observer interface {
+	OnWrite(kind string, bytes int64, dur time.Duration)
+}
+
+// DefaultObserver, when non-nil, observes every write that does not set its own observer with WithObserver.
+var DefaultObserver Observer
+
+// WithObserver reports the write to o instead of DefaultObserver.
+func WithObserver(o Observer) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.observer = o
+	}
+}
+
+// withKind sets the artifact kind reported to the observer.
+func withKind(kind string) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.kind = kind
+	}
+}
@@ -0,0 +1,55 @@
+package utilities
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	mu     sync.Mutex
+	kinds  []string
+	nbytes []int64
+}
+
+func (o *recordingObserver) OnWrite(kind string, bytes int64, _ time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.kinds = append(o.kinds, kind)
+	o.nbytes = append(o.nbytes, bytes)
+}
+
+func Test_Observer(t *testing.T) {
+	proof, vk, _ := genTestProof(t)
+	dir := t.TempDir()
+
+	perCall := &recordingObserver{}
+	if err := WriteProof(proof, filepath.Join(dir, "proof"), WithObserver(perCall)); err != nil {
+		t.Fatal(err)
+	}
+	proofBytes, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(perCall.kinds) != 1 || perCall.kinds[0] != "proof" || perCall.nbytes[0] != int64(len(proofBytes)) {
+		t.Fatalf("unexpected observations %v %v", perCall.kinds, perCall.nbytes)
+	}
+
+	global := &recordingObserver{}
+	DefaultObserver = global
+	defer func() { DefaultObserver = nil }()
+
+	if err := WriteVk(vk, filepath.Join(dir, "vk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVkInSolidity(vk, filepath.Join(dir, "Verifier.sol")); err != nil {
+		t.Fatal(err)
+	}
+	if len(global.kinds) != 2 || global.kinds[0] != "vk" || global.kinds[1] != "file" {
+		t.Fatalf("unexpected observations %v", global.kinds)
+	}
+	if len(perCall.kinds) != 1 {
+		t.Fatal("expected the per call observer not to see later writes")
+	}
+}
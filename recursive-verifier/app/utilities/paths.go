@@ -0,0 +1,74 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPathTraversal is returned when a filename resolves to a location outside of the allowed base directory.
+var ErrPathTraversal = errors.New("path escapes base directory")
+
+// BaseDir, when non-empty, confines OpenFileOnCreateOrOverwrite and every Write* helper built on it to files
+// within this directory. Relative names are written relative to it, not to the working directory, and targets
+// outside of it are rejected with ErrPathTraversal before anything is created.
+var BaseDir string
+
+// WithinBaseDir resolves fn against base and returns the cleaned absolute path if it lies within base.
+// Relative names are interpreted relative to base. Symlinks in the existing part of either path are
+// resolved first, so a link pointing out of base does not pass the check.
+func WithinBaseDir(base, fn string) (string, error) {
+	resolvedBase, err := resolvePath(base)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve base directory %s: %w", base, err)
+	}
+
+	if !filepath.IsAbs(fn) {
+		fn = filepath.Join(resolvedBase, fn)
+	}
+	resolved, err := resolvePath(fn)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", fn, err)
+	}
+
+	rel, err := filepath.Rel(resolvedBase, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s is not within %s", ErrPathTraversal, fn, base)
+	}
+
+	return resolved, nil
+}
+
+// resolvePath returns the absolute, cleaned form of p with symlinks evaluated for the longest existing prefix.
+func resolvePath(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+
+	var missing []string
+	for dir := abs; ; dir = filepath.Dir(dir) {
+		resolved, err := filepath.EvalSymlinks(dir)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if filepath.Dir(dir) == dir {
+			return abs, nil
+		}
+		missing = append([]string{filepath.Base(dir)}, missing...)
+	}
+}
+
+// checkBaseDir applies the BaseDir confinement to file, if one is configured, and returns the path to write.
+// Writers must open the returned path: a relative file is resolved against BaseDir, not the working directory.
+func checkBaseDir(file string) (string, error) {
+	if BaseDir == "" {
+		return file, nil
+	}
+	return WithinBaseDir(BaseDir, file)
+}
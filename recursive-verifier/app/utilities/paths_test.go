@@ -0,0 +1,91 @@
+package utilities
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_WithinBaseDir(t *testing.T) {
+	base := t.TempDir()
+
+	for _, fn := range []string{"proof", "out/vk", filepath.Join(base, "a", "..", "proof")} {
+		if _, err := WithinBaseDir(base, fn); err != nil {
+			t.Errorf("expected %s to be accepted: %v", fn, err)
+		}
+	}
+
+	for _, fn := range []string{"../proof", "out/../../proof", "/etc/passwd"} {
+		if _, err := WithinBaseDir(base, fn); !errors.Is(err, ErrPathTraversal) {
+			t.Errorf("expected ErrPathTraversal for %s, got %v", fn, err)
+		}
+	}
+
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(base, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WithinBaseDir(base, "link/proof"); !errors.Is(err, ErrPathTraversal) {
+		t.Errorf("expected ErrPathTraversal through symlink, got %v", err)
+	}
+}
+
+func Test_WriteRejectsPathOutsideBaseDir(t *testing.T) {
+	base := t.TempDir()
+	BaseDir = base
+	defer func() { BaseDir = "" }()
+
+	outside := filepath.Join(t.TempDir(), "escaped")
+	err := WriteAtomic(outside, func(w io.Writer) error { return nil })
+	if !errors.Is(err, ErrPathTraversal) {
+		t.Fatalf("expected ErrPathTraversal, got %v", err)
+	}
+	if exists, _ := FileExists(outside); exists {
+		t.Fatal("expected nothing to be written outside the base directory")
+	}
+
+	if err := WriteAtomic(filepath.Join(base, "inside"), func(w io.Writer) error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_WriteResolvesRelativePathAgainstBaseDir(t *testing.T) {
+	base, cwd := t.TempDir(), t.TempDir()
+	BaseDir = base
+	defer func() { BaseDir = "" }()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = os.Chdir(wd) }()
+
+	write := func(w io.Writer) error {
+		_, err := io.WriteString(w, "data")
+		return err
+	}
+	if err := WriteAtomic("out.txt", write); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "out.txt")); err != nil {
+		t.Fatalf("expected out.txt within the base directory: %v", err)
+	}
+	if exists, _ := FileExists(filepath.Join(cwd, "out.txt")); exists {
+		t.Fatal("expected nothing to be written to the working directory")
+	}
+
+	proof, _, _ := testutil.GenTestProof(t)
+	if err := AppendProof(proof, "batch.bin"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(base, "batch.bin")); err != nil {
+		t.Fatalf("expected batch.bin within the base directory: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+package utilities
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	plonk_bn254 "github.com/consensys/gnark/backend/plonk/bn254"
+)
+
+func WritePlonkProof(proof plonk.Proof, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		_, err := proof.WriteTo(w)
+		return err
+	})
+}
+
+// ReadPlonkProof restores a BN254 PLONK proof written by WritePlonkProof.
+func ReadPlonkProof(fn string) (plonk.Proof, error) {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open plonk proof file %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	var bn254Proof plonk_bn254.Proof
+	_, err = bn254Proof.ReadFrom(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plonk proof file %s: %w", fn, err)
+	}
+
+	return &bn254Proof, nil
+}
+
+// WritePlonkVkInSolidity writes the PLONK verifier contract for vk. Unlike the groth16 contract, it exposes
+// Verify(bytes proof, uint256[] public_inputs), taking the proof as the raw bytes of plonk_bn254.Proof.MarshalSolidity.
+func WritePlonkVkInSolidity(vk plonk.VerifyingKey, fn string) error {
+	if _, ok := vk.(*plonk_bn254.VerifyingKey); !ok {
+		return fmt.Errorf("solidity export is only supported on %s, got %T", ecc.BN254, vk)
+	}
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return vk.ExportSolidity(w)
+	})
+}
+
+// WritePlonkProofInSolidity writes the proof argument of the PLONK verifier's Verify as a 0x prefixed hex string.
+func WritePlonkProofInSolidity(proof plonk.Proof, fn string) error {
+	_proof, ok := proof.(*plonk_bn254.Proof)
+	if !ok {
+		return fmt.Errorf("solidity export is only supported on %s, got %T", ecc.BN254, proof)
+	}
+
+	return WriteAtomic(fn, func(w io.Writer) error {
+		_, err := io.WriteString(w, "0x"+hex.EncodeToString(_proof.MarshalSolidity()))
+		return err
+	})
+}
@@ -0,0 +1,74 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test/unsafekzg"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_PlonkProofRoundTrip(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srs, srsLagrange, err := unsafekzg.NewSRS(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := plonk.Setup(ccs, srs, srsLagrange)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fullWitness, err := frontend.NewWitness(&testutil.SquareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := plonk.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	proofFn := filepath.Join(dir, "plonk_proof")
+	if err := WritePlonkProof(proof, proofFn); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadPlonkProof(proofFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plonk.Verify(restored, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	solFn := filepath.Join(dir, "PlonkVerifier.sol")
+	if err := WritePlonkVkInSolidity(vk, solFn); err != nil {
+		t.Fatal(err)
+	}
+	sol, err := os.ReadFile(solFn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sol), "function Verify(bytes calldata proof") {
+		t.Fatal("expected the PLONK verifier contract layout")
+	}
+
+	proofSolFn := filepath.Join(dir, "plonk_proof_solidity")
+	if err := WritePlonkProofInSolidity(proof, proofSolFn); err != nil {
+		t.Fatal(err)
+	}
+}
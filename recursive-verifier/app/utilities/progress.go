@@ -0,0 +1,84 @@
+package utilities
+
+import (
+	"io"
+	"io/fs"
+)
+
+// ProgressFunc is called as bytes are read or written with the running byte count and the total size.
+// total is the file size for reads and -1 for writes, whose size is only known once serialization finishes.
+// It is called for every chunk and should be cheap, rate limit rendering on the caller's side.
+type ProgressFunc func(done, total int64)
+
+// WithProgress reports the progress of a write to fn.
+func WithProgress(fn ProgressFunc) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.progress = fn
+	}
+}
+
+// ReadOption configures the readers that accept options, such as ReadPk, ReadCcs and ReadVk.
+type ReadOption func(*readConfig)
+
+type readConfig struct {
+	progress   ProgressFunc
+	validateVk bool
+}
+
+// WithReadProgress reports the progress of a read to fn.
+func WithReadProgress(fn ProgressFunc) ReadOption {
+	return func(cfg *readConfig) {
+		cfg.progress = fn
+	}
+}
+
+func newReadConfig(opts []ReadOption) readConfig {
+	var cfg readConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// wrapReader returns f wrapped to report progress against the size of f, or f itself without a callback.
+func (cfg readConfig) wrapReader(f fs.File) (io.Reader, error) {
+	if cfg.progress == nil {
+		return f, nil
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &progressReader{r: f, total: info.Size(), fn: cfg.progress}, nil
+}
+
+type progressReader struct {
+	r     io.Reader
+	done  int64
+	total int64
+	fn    ProgressFunc
+}
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	if n > 0 {
+		pr.done += int64(n)
+		pr.fn(pr.done, pr.total)
+	}
+	return n, err
+}
+
+type progressWriter struct {
+	w    io.Writer
+	done int64
+	fn   ProgressFunc
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	if n > 0 {
+		pw.done += int64(n)
+		pw.fn(pw.done, -1)
+	}
+	return n, err
+}
@@ -0,0 +1,45 @@
+package utilities
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+func Test_Progress(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "circuit.ccs")
+	var written int64
+	err = WriteCcs(ccs, fn, WithProgress(func(done, total int64) {
+		if total != -1 {
+			t.Errorf("expected an unknown total for writes, got %d", total)
+		}
+		written = done
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written == 0 {
+		t.Fatal("expected write progress to be reported")
+	}
+
+	var read, size int64
+	_, err = ReadCcs(fn, WithReadProgress(func(done, total int64) {
+		read, size = done, total
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != written || read != size {
+		t.Fatalf("expected to read all %d bytes, got %d of %d", written, read, size)
+	}
+}
@@ -0,0 +1,85 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+)
+
+// ProofFormat selects the encoding of the files written by WriteProof.
+type ProofFormat int
+
+const (
+	// ProofFormatRaw is gnark's proof serialization as is.
+	ProofFormatRaw ProofFormat = iota
+	// ProofFormatV1 prefixes gnark's serialization with a ProofHeaderSize byte header: the magic "gprf",
+	// the format version 1, the backend.ID as one byte and the ecc.ID as a big-endian uint16.
+	ProofFormatV1
+)
+
+// ProofFileFormat is the format WriteProof writes, ProofFormatRaw unless a pipeline opts in to the header.
+// ReadProof and friends accept both: a file starting with the magic is checked against the expected curve
+// and backend, any other file is decoded as a headerless proof. The magic never starts a headerless BN254 or
+// BLS12-381 proof: its first byte is neither a valid point compression flag nor below the field modulus.
+var ProofFileFormat = ProofFormatRaw
+
+// ProofHeaderSize is the length of the ProofFormatV1 header.
+const ProofHeaderSize = 8
+
+var proofMagic = []byte("gprf")
+
+const proofHeaderVersion = 1
+
+func proofHeader(curveID ecc.ID) []byte {
+	header := make([]byte, ProofHeaderSize)
+	copy(header, proofMagic)
+	header[4] = proofHeaderVersion
+	header[5] = byte(backend.GROTH16)
+	binary.BigEndian.PutUint16(header[6:], uint16(curveID))
+	return header
+}
+
+func hasProofHeader(data []byte) bool {
+	return bytes.HasPrefix(data, proofMagic)
+}
+
+// stripProofHeader returns the gnark serialization within data, checking the header if there is one.
+func stripProofHeader(data []byte, curveID ecc.ID) ([]byte, error) {
+	if !hasProofHeader(data) {
+		return data, nil
+	}
+	if len(data) < ProofHeaderSize {
+		return nil, fmt.Errorf("truncated proof header of %d bytes: %w", len(data), io.ErrUnexpectedEOF)
+	}
+	if version := data[4]; version != proofHeaderVersion {
+		return nil, fmt.Errorf("unsupported proof format version %d", version)
+	}
+	if id := backend.ID(data[5]); id != backend.GROTH16 {
+		return nil, fmt.Errorf("file holds a %s proof, expected a %s one", id, backend.GROTH16)
+	}
+	if id := ecc.ID(binary.BigEndian.Uint16(data[6:])); id != curveID {
+		return nil, fmt.Errorf("file holds a proof on %s, expected %s", id, curveID)
+	}
+	return data[ProofHeaderSize:], nil
+}
+
+// fileHasProofHeader reports whether fn starts with the proof magic, false if it cannot be read.
+func fileHasProofHeader(fn string) bool {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return false
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	magic := make([]byte, len(proofMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return hasProofHeader(magic)
+}
@@ -0,0 +1,70 @@
+package utilities
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+func Test_ProofHeader(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+	raw := filepath.Join(dir, "raw.proof")
+	headed := filepath.Join(dir, "headed")
+
+	if err := WriteProof(proof, raw); err != nil {
+		t.Fatal(err)
+	}
+	ProofFileFormat = ProofFormatV1
+	defer func() { ProofFileFormat = ProofFormatRaw }()
+	if err := WriteProof(proof, headed, WithVerifyAfterWrite()); err != nil {
+		t.Fatal(err)
+	}
+
+	rawData, err := os.ReadFile(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	headedData, err := os.ReadFile(headed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(headedData[:ProofHeaderSize], proofHeader(ecc.BN254)) || !bytes.Equal(headedData[ProofHeaderSize:], rawData) {
+		t.Fatal("expected the header followed by the raw serialization")
+	}
+
+	// both formats are read back, whatever ProofFileFormat is
+	for _, fn := range []string{raw, headed} {
+		p, err := ReadProof(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(p, vk, publicWitness); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := ReadProofWithCurve(headed, ecc.BLS12_381); err == nil || !strings.Contains(err.Error(), "expected bls12_381") {
+		t.Fatalf("expected a curve mismatch error, got %v", err)
+	}
+
+	artifact, kind, err := ReadArtifact(headed)
+	if err != nil || kind != ArtifactProof {
+		t.Fatalf("expected the headed file to be detected as a proof, got %v (%v)", kind, err)
+	}
+	if _, ok := artifact.(groth16.Proof); !ok {
+		t.Fatalf("unexpected artifact %T", artifact)
+	}
+	asVk := filepath.Join(dir, "headed.vk")
+	if err := os.WriteFile(asVk, headedData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := ReadArtifact(asVk); err == nil {
+		t.Fatal("expected a proof named as a vk to be rejected")
+	}
+}
@@ -0,0 +1,54 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// ProofWriter writes proofs like WriteProof, reusing its serialization buffers across calls instead of
+// allocating them for every proof. It is meant for provers writing many proofs, keep one for the lifetime
+// of the service. A ProofWriter is safe for concurrent use, each call borrows its own buffer.
+type ProofWriter struct {
+	pool sync.Pool
+	opts []WriteOption
+}
+
+// NewProofWriter returns a ProofWriter applying opts to every write, as WriteProof applies its options.
+func NewProofWriter(opts ...WriteOption) *ProofWriter {
+	return &ProofWriter{
+		pool: sync.Pool{New: func() any { return new(bytes.Buffer) }},
+		opts: append([]WriteOption{withKind("proof")}, opts...),
+	}
+}
+
+// Write writes proof to fn. The file content is the same as WriteProof writes with the same options.
+func (pw *ProofWriter) Write(proof groth16.Proof, fn string) error {
+	buf := pw.pool.Get().(*bytes.Buffer)
+	defer pw.pool.Put(buf)
+	buf.Reset()
+
+	if ProofFileFormat == ProofFormatV1 {
+		buf.Write(proofHeader(proof.CurveID()))
+	}
+	headerLen := buf.Len()
+	if err := WriteProofTo(buf, proof); err != nil {
+		return fmt.Errorf("failed to marshal proof: %w", err)
+	}
+
+	err := WriteAtomic(fn, func(w io.Writer) error {
+		_, err := w.Write(buf.Bytes())
+		return err
+	}, pw.opts...)
+	if err != nil {
+		return err
+	}
+
+	if !newWriteConfig(pw.opts).verify || DryRun != nil {
+		return nil
+	}
+	return verifyWrittenProof(fn, proof.CurveID(), buf.Bytes()[headerLen:])
+}
@@ -0,0 +1,84 @@
+package utilities
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+func Test_ProofWriter(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+
+	want := filepath.Join(dir, "want.proof")
+	if err := WriteProof(proof, want); err != nil {
+		t.Fatal(err)
+	}
+	wantData, err := os.ReadFile(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pw := NewProofWriter(WithVerifyAfterWrite())
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = pw.Write(proof, filepath.Join(dir, fmt.Sprintf("%d.proof", i)))
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn := filepath.Join(dir, fmt.Sprintf("%d.proof", i))
+		data, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, wantData) {
+			t.Fatalf("%s differs from the output of WriteProof", fn)
+		}
+		p, err := ReadProof(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(p, vk, publicWitness); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkWriteProof(b *testing.B) {
+	proof, _, _ := genTestProof(b)
+	fn := filepath.Join(b.TempDir(), "proof")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := WriteProof(proof, fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProofWriter(b *testing.B) {
+	proof, _, _ := genTestProof(b)
+	fn := filepath.Join(b.TempDir(), "proof")
+	pw := NewProofWriter()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := pw.Write(proof, fn); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package utilities
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	stdgroth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+// RecursiveWitness holds the in-circuit values of a BN254 inner proof, its verifying key and public inputs,
+// as emulated field elements in the layout std/recursion/groth16's verifier gadget expects. Embed its
+// fields in the outer circuit and pass them to stdgroth16.Verifier.AssertProof.
+type RecursiveWitness struct {
+	Proof        stdgroth16.Proof[sw_bn254.G1Affine, sw_bn254.G2Affine]
+	VerifyingKey stdgroth16.VerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl]
+	PublicInputs stdgroth16.Witness[sw_bn254.ScalarField]
+}
+
+// BuildRecursiveWitness converts an inner BN254 proof, verifying key and witness into a RecursiveWitness
+// assignment. Commitments and the commitment proof of knowledge are carried over, the inner proof must then
+// have been created with stdgroth16.GetNativeProverOptions so the gadget recomputes the same challenge.
+// Only the public part of innerPublic is used.
+func BuildRecursiveWitness(innerProof groth16.Proof, innerVk groth16.VerifyingKey, innerPublic witness.Witness) (RecursiveWitness, error) {
+	if err := requireBN254(innerProof.CurveID()); err != nil {
+		return RecursiveWitness{}, err
+	}
+	if err := requireBN254(innerVk.CurveID()); err != nil {
+		return RecursiveWitness{}, err
+	}
+
+	proof, err := stdgroth16.ValueOfProof[sw_bn254.G1Affine, sw_bn254.G2Affine](innerProof)
+	if err != nil {
+		return RecursiveWitness{}, fmt.Errorf("failed to convert inner proof: %w", err)
+	}
+	vk, err := stdgroth16.ValueOfVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](innerVk)
+	if err != nil {
+		return RecursiveWitness{}, fmt.Errorf("failed to convert inner verifying key: %w", err)
+	}
+	public, err := stdgroth16.ValueOfWitness[sw_bn254.ScalarField](innerPublic)
+	if err != nil {
+		return RecursiveWitness{}, fmt.Errorf("failed to convert inner public witness: %w", err)
+	}
+
+	return RecursiveWitness{Proof: proof, VerifyingKey: vk, PublicInputs: public}, nil
+}
+
+// PlaceholderRecursiveWitness returns the RecursiveWitness shape matching innerCcs, for use when compiling
+// the outer circuit.
+func PlaceholderRecursiveWitness(innerCcs constraint.ConstraintSystem) RecursiveWitness {
+	return RecursiveWitness{
+		Proof:        stdgroth16.PlaceholderProof[sw_bn254.G1Affine, sw_bn254.G2Affine](innerCcs),
+		VerifyingKey: stdgroth16.PlaceholderVerifyingKey[sw_bn254.G1Affine, sw_bn254.G2Affine, sw_bn254.GTEl](innerCcs),
+		PublicInputs: stdgroth16.PlaceholderWitness[sw_bn254.ScalarField](innerCcs),
+	}
+}
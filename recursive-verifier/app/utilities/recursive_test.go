@@ -0,0 +1,72 @@
+package utilities
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/std/algebra/emulated/sw_bn254"
+	stdgroth16 "github.com/consensys/gnark/std/recursion/groth16"
+)
+
+type committedSquareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *committedSquareCircuit) Define(api frontend.API) error {
+	commitment, err := api.(frontend.Committer).Commit(c.X)
+	if err != nil {
+		return err
+	}
+	api.AssertIsDifferent(commitment, 0)
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+func Test_BuildRecursiveWitness(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &committedSquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&committedSquareCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness, stdgroth16.GetNativeProverOptions(ecc.BN254.ScalarField(), ecc.BN254.ScalarField()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := BuildRecursiveWitness(proof, vk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_proof := proof.(*groth16_bn254.Proof)
+	if !reflect.DeepEqual(w.Proof.Ar, sw_bn254.NewG1Affine(_proof.Ar)) || !reflect.DeepEqual(w.Proof.Bs, sw_bn254.NewG2Affine(_proof.Bs)) {
+		t.Fatal("proof points were not carried over")
+	}
+	if len(w.Proof.Commitments) != 1 || !reflect.DeepEqual(w.Proof.Commitments[0].G1El, sw_bn254.NewG1Affine(_proof.Commitments[0])) {
+		t.Fatal("expected the commitment to be carried over")
+	}
+	if !reflect.DeepEqual(w.Proof.CommitmentPok.G1El, sw_bn254.NewG1Affine(_proof.CommitmentPok)) {
+		t.Fatal("expected the commitment pok to be carried over")
+	}
+	if len(w.PublicInputs.Public) != 1 {
+		t.Fatalf("expected only the public input, got %d", len(w.PublicInputs.Public))
+	}
+
+	placeholder := PlaceholderRecursiveWitness(ccs)
+	if len(placeholder.Proof.Commitments) != len(w.Proof.Commitments) || len(placeholder.VerifyingKey.G1.K) != len(w.VerifyingKey.G1.K) {
+		t.Fatal("placeholder shape does not match the assignment")
+	}
+}
@@ -0,0 +1,175 @@
+package utilities
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// VkRegistry collects verifying keys keyed by circuit id for storage in a single file. Each entry is
+// written as two frames in the WriteProofBatch framing: the id, then the key serialized with WriteTo.
+type VkRegistry struct {
+	ids []string
+	vks map[string]groth16.VerifyingKey
+}
+
+// Add registers vk under id. Ids must be unique and non-empty.
+func (r *VkRegistry) Add(id string, vk groth16.VerifyingKey) error {
+	if id == "" {
+		return errors.New("verifying key id must not be empty")
+	}
+	if _, ok := r.vks[id]; ok {
+		return fmt.Errorf("duplicate verifying key id %q", id)
+	}
+	if r.vks == nil {
+		r.vks = make(map[string]groth16.VerifyingKey)
+	}
+	r.ids = append(r.ids, id)
+	r.vks[id] = vk
+	return nil
+}
+
+// WriteTo writes the registry entries to w in the order they were added.
+func (r *VkRegistry) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	for _, id := range r.ids {
+		var buf bytes.Buffer
+		if _, err := r.vks[id].WriteTo(&buf); err != nil {
+			return cw.n, fmt.Errorf("failed to serialize verifying key %q: %w", id, err)
+		}
+		if err := writeFrame(cw, []byte(id)); err != nil {
+			return cw.n, err
+		}
+		if err := writeFrame(cw, buf.Bytes()); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// WriteVkRegistry writes r into fn.
+func WriteVkRegistry(r *VkRegistry, fn string, opts ...WriteOption) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		_, err := r.WriteTo(w)
+		return err
+	}, append([]WriteOption{withKind("vk")}, opts...)...)
+}
+
+// ReadVkRegistry restores the BN254 verifying keys written by WriteVkRegistry. A file holding the same id
+// twice is rejected.
+func ReadVkRegistry(fn string) (map[string]groth16.VerifyingKey, error) {
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open verifying key registry %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	r := bufio.NewReader(f)
+
+	vks := make(map[string]groth16.VerifyingKey)
+	for {
+		id, err := readFrame(r)
+		if errors.Is(err, io.EOF) {
+			return vks, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read entry %d of %s: %w", len(vks), fn, err)
+		}
+		if _, ok := vks[string(id)]; ok {
+			return nil, fmt.Errorf("duplicate verifying key id %q in %s", id, fn)
+		}
+
+		data, err := readFrame(r)
+		if errors.Is(err, io.EOF) {
+			err = fmt.Errorf("missing verifying key: %w", io.ErrUnexpectedEOF)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read verifying key %q from %s: %w", id, fn, err)
+		}
+
+		if err := checkVkCounts(data); err != nil {
+			return nil, fmt.Errorf("failed to decode verifying key %q from %s: %w", id, fn, err)
+		}
+		var vk groth16_bn254.VerifyingKey
+		if _, err := vk.ReadFrom(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("failed to decode verifying key %q from %s: %w", id, fn, err)
+		}
+		vks[string(id)] = &vk
+	}
+}
+
+var errVkCount = errors.New("too many verifying key elements")
+
+// checkVkCounts walks data, a key laid out as groth16_bn254.VerifyingKey.WriteTo and WriteRawTo write it,
+// and checks the untrusted lengths it holds against the bytes left, as ReadFrom allocates for them before
+// reading their elements.
+func checkVkCounts(data []byte) error {
+	truncated := fmt.Errorf("truncated verifying key of %d bytes: %w", len(data), io.ErrUnexpectedEOF)
+	pos := 0
+	skipPoint := func(compressedSize int) error {
+		if pos >= len(data) {
+			return truncated
+		}
+		// the two most significant bits of a point are only both zero when it is uncompressed
+		size := compressedSize
+		if data[pos]>>6 == 0 {
+			size *= 2
+		}
+		if size > len(data)-pos {
+			return truncated
+		}
+		pos += size
+		return nil
+	}
+	readCount := func(name string, minSize int) (int, error) {
+		if len(data)-pos < 4 {
+			return 0, truncated
+		}
+		n := binary.BigEndian.Uint32(data[pos:])
+		pos += 4
+		if int64(n)*int64(minSize) > int64(len(data)-pos) {
+			return 0, fmt.Errorf("%w: %d %s do not fit in %d bytes: %w", errVkCount, n, name, len(data)-pos, io.ErrUnexpectedEOF)
+		}
+		return int(n), nil
+	}
+
+	// [α]1, [β]1, [β]2, [γ]2, [δ]1, [δ]2
+	for _, size := range []int{bn254.SizeOfG1AffineCompressed, bn254.SizeOfG1AffineCompressed, bn254.SizeOfG2AffineCompressed,
+		bn254.SizeOfG2AffineCompressed, bn254.SizeOfG1AffineCompressed, bn254.SizeOfG2AffineCompressed} {
+		if err := skipPoint(size); err != nil {
+			return err
+		}
+	}
+	nbK, err := readCount("K points", bn254.SizeOfG1AffineCompressed)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < nbK; i++ {
+		if err := skipPoint(bn254.SizeOfG1AffineCompressed); err != nil {
+			return err
+		}
+	}
+	// PublicAndCommitmentCommitted, a list of uint64 lists each prefixed with its uint32 length
+	nbCommitted, err := readCount("committed input lists", 4)
+	if err != nil {
+		return err
+	}
+	for i := 0; i < nbCommitted; i++ {
+		n, err := readCount("committed inputs", 8)
+		if err != nil {
+			return err
+		}
+		pos += 8 * n
+	}
+	// every commitment key holds two G2 points
+	_, err = readCount("commitment keys", 2*bn254.SizeOfG2AffineCompressed)
+	return err
+}
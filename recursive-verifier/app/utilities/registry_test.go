@@ -0,0 +1,108 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+func Test_VkRegistryRoundTrip(t *testing.T) {
+	_, vkA, _ := genTestProof(t)
+	_, vkB, _ := genTestProof(t)
+
+	var registry VkRegistry
+	if err := registry.Add("square-a", vkA); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Add("square-b", vkB); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Add("square-a", vkB); err == nil {
+		t.Fatal("expected Add to reject a duplicate id")
+	}
+
+	fn := filepath.Join(t.TempDir(), "vks.registry")
+	if err := WriteVkRegistry(&registry, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	vks, err := ReadVkRegistry(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vks) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(vks))
+	}
+	if equal, diff := VkEqual(vkA, vks["square-a"]); !equal {
+		t.Fatalf("square-a: %s", diff)
+	}
+	if equal, diff := VkEqual(vkB, vks["square-b"]); !equal {
+		t.Fatalf("square-b: %s", diff)
+	}
+}
+
+func Test_ReadVkRegistry_RejectsDuplicateIds(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+
+	var single VkRegistry
+	if err := single.Add("square", vk); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := single.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "vks.registry")
+	if err := os.WriteFile(fn, append(buf.Bytes(), buf.Bytes()...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVkRegistry(fn); err == nil {
+		t.Fatal("expected an error for a duplicate id")
+	}
+}
+
+func Test_ReadVkRegistry_BoundsCounts(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+	var key bytes.Buffer
+	if _, err := vk.WriteTo(&key); err != nil {
+		t.Fatal(err)
+	}
+	_, commitVk, _ := genCommitProof(t)
+	var commitKey bytes.Buffer
+	if _, err := commitVk.WriteTo(&commitKey); err != nil {
+		t.Fatal(err)
+	}
+	var rawKey bytes.Buffer
+	if _, err := commitVk.(*groth16_bn254.VerifyingKey).WriteRawTo(&rawKey); err != nil {
+		t.Fatal(err)
+	}
+	for _, data := range [][]byte{key.Bytes(), commitKey.Bytes(), rawKey.Bytes()} {
+		if err := checkVkCounts(data); err != nil {
+			t.Fatalf("expected the counts of a valid key to be accepted, got %v", err)
+		}
+	}
+
+	// the length of G1.K follows [α]1, [β]1, [β]2, [γ]2, [δ]1 and [δ]2, all compressed
+	data := key.Bytes()
+	binary.BigEndian.PutUint32(data[3*bn254.SizeOfG1AffineCompressed+3*bn254.SizeOfG2AffineCompressed:], 0x7fffffff)
+	var buf bytes.Buffer
+	for _, frame := range [][]byte{[]byte("square"), data} {
+		if err := writeFrame(&buf, frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fn := filepath.Join(t.TempDir(), "vks.registry")
+	if err := os.WriteFile(fn, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVkRegistry(fn); !errors.Is(err, errVkCount) {
+		t.Fatalf("expected the K count to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+package utilities
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// DefaultPkChunkSize is the chunk size WritePkResumable records progress at.
+const DefaultPkChunkSize int64 = 64 << 20
+
+const (
+	partialExt  = ".partial"
+	manifestExt = ".chunks"
+)
+
+// ErrChunkMismatch is returned when a chunk of a resumable write does not match the hash recorded for it.
+var ErrChunkMismatch = errors.New("chunk hash mismatch")
+
+// pkManifest is the <fn>.chunks sidecar of WritePkResumable, holding the hex encoded SHA-256 digest of every
+// chunk written so far. Only the last chunk of a complete write may be shorter than ChunkSize.
+type pkManifest struct {
+	ChunkSize int64    `json:"chunkSize"`
+	Chunks    []string `json:"chunks"`
+	Complete  bool     `json:"complete"`
+}
+
+// resumableFilesystem is implemented by filesystems WritePkResumable can continue a partial file on.
+type resumableFilesystem interface {
+	Filesystem
+	appendFilesystem
+	Truncate(name string, size int64) error
+}
+
+func (OSFilesystem) Truncate(name string, size int64) error {
+	return os.Truncate(name, size)
+}
+
+func (m *MemFilesystem) Truncate(name string, size int64) error {
+	name = filepath.Clean(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.files[name]
+	if !ok {
+		return &fs.PathError{Op: "truncate", Path: name, Err: fs.ErrNotExist}
+	}
+	if size < 0 || size > int64(len(e.data)) {
+		return &fs.PathError{Op: "truncate", Path: name, Err: fs.ErrInvalid}
+	}
+	e.data = e.data[:size]
+	return nil
+}
+
+// WritePkResumable writes pk like WritePk, in chunks of chunkSize bytes, DefaultPkChunkSize if chunkSize <= 0,
+// so that a write interrupted by a crash or a full disk can be continued by calling it again with the same
+// key. The file is built as <fn>.partial and every chunk is synced before its hash is recorded in the
+// <fn>.chunks sidecar. On resume, the chunks already on disk are checked against their recorded hash, anything
+// past the last valid one is truncated, and the key serialization, which cannot seek, is recomputed from the
+// start: already written chunks are compared by hash and skipped instead of written again. Resuming with
+// another key or chunk size fails with ErrChunkMismatch, remove the partial file and its sidecar to start over.
+// Once complete, <fn>.partial is renamed to fn and the sidecar is kept for ReadPkChunked.
+func WritePkResumable(pk groth16.ProvingKey, fn string, chunkSize int64) error {
+	if chunkSize <= 0 {
+		chunkSize = DefaultPkChunkSize
+	}
+	if DryRun != nil {
+		return WritePk(pk, fn)
+	}
+	fn, err := checkBaseDir(fn)
+	if err != nil {
+		return err
+	}
+	rfs, ok := DefaultFilesystem.(resumableFilesystem)
+	if !ok {
+		return fmt.Errorf("filesystem %T does not support resumable writes", DefaultFilesystem)
+	}
+	if err := CheckOrCreateDir(fn); err != nil {
+		return err
+	}
+
+	partial := fn + partialExt
+	m, err := resumePkManifest(rfs, fn, chunkSize)
+	if err != nil {
+		return err
+	}
+
+	out, err := rfs.OpenAppend(partial, SensitiveFileMode)
+	if err != nil {
+		return fmt.Errorf("failed to open partial proving key file %s: %w", partial, err)
+	}
+	cw := &chunkWriter{
+		manifest: m,
+		out:      out,
+		buf:      make([]byte, 0, chunkSize),
+		persist:  func() error { return writePkManifest(fn, m) },
+	}
+	if err := WritePkTo(cw, pk); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to write proving key file %s: %w", fn, err)
+	}
+	if err := cw.finish(); err != nil {
+		_ = out.Close()
+		return fmt.Errorf("failed to write proving key file %s: %w", fn, err)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("failed to close partial proving key file %s: %w", partial, err)
+	}
+
+	if err := rfs.Rename(partial, fn); err != nil {
+		return fmt.Errorf("failed to rename partial proving key file to %s: %w", fn, err)
+	}
+	m.Complete = true
+	return writePkManifest(fn, m)
+}
+
+// resumePkManifest returns the manifest of the chunks of <fn>.partial that can be kept, truncating the
+// partial file to them, or an empty manifest for a fresh write.
+func resumePkManifest(rfs resumableFilesystem, fn string, chunkSize int64) (*pkManifest, error) {
+	partial := fn + partialExt
+	fresh := &pkManifest{ChunkSize: chunkSize, Chunks: []string{}}
+
+	m, err := readPkManifest(fn)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && m.Complete {
+		if err := rfs.Remove(partial); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale partial proving key file %s: %w", partial, err)
+		}
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if m.ChunkSize != chunkSize {
+		return nil, fmt.Errorf("%w: %s was started with chunks of %d bytes, not %d", ErrChunkMismatch, partial, m.ChunkSize, chunkSize)
+	}
+
+	f, err := rfs.Open(partial)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fresh, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open partial proving key file %s: %w", partial, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	valid := 0
+	buf := make([]byte, chunkSize)
+	for valid < len(m.Chunks) {
+		if _, err := io.ReadFull(f, buf); err != nil {
+			break
+		}
+		if chunkHash(buf) != m.Chunks[valid] {
+			break
+		}
+		valid++
+	}
+	m.Chunks = m.Chunks[:valid]
+	if err := rfs.Truncate(partial, int64(valid)*chunkSize); err != nil {
+		return nil, fmt.Errorf("failed to truncate partial proving key file %s: %w", partial, err)
+	}
+	logDebug("resuming proving key write", "file", fn, "chunks", valid)
+	return m, nil
+}
+
+func readPkManifest(fn string) (*pkManifest, error) {
+	data, err := readFile(fn + manifestExt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chunk manifest of %s: %w", fn, err)
+	}
+	var m pkManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse chunk manifest of %s: %w", fn, err)
+	}
+	if m.ChunkSize <= 0 {
+		return nil, fmt.Errorf("invalid chunk size %d in chunk manifest of %s", m.ChunkSize, fn)
+	}
+	return &m, nil
+}
+
+func writePkManifest(fn string, m *pkManifest) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("failed to marshal chunk manifest of %s: %w", fn, err)
+	}
+	err = WriteAtomic(fn+manifestExt, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write chunk manifest of %s: %w", fn, err)
+	}
+	return nil
+}
+
+func chunkHash(chunk []byte) string {
+	sum := sha256.Sum256(chunk)
+	return hex.EncodeToString(sum[:])
+}
+
+// chunkWriter cuts the serialization into chunks, skipping those the manifest already holds.
+type chunkWriter struct {
+	manifest *pkManifest
+	out      WritableFile
+	buf      []byte
+	index    int
+	persist  func() error
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		k := min(len(p), cap(w.buf)-len(w.buf))
+		w.buf = append(w.buf, p[:k]...)
+		p = p[k:]
+		if len(w.buf) == cap(w.buf) {
+			if err := w.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *chunkWriter) flush() error {
+	sum := chunkHash(w.buf)
+	if w.index < len(w.manifest.Chunks) {
+		if w.manifest.Chunks[w.index] != sum {
+			return fmt.Errorf("%w: chunk %d differs from the partial write, the proving key changed", ErrChunkMismatch, w.index)
+		}
+	} else {
+		if _, err := w.out.Write(w.buf); err != nil {
+			return err
+		}
+		if err := w.out.Sync(); err != nil {
+			return err
+		}
+		w.manifest.Chunks = append(w.manifest.Chunks, sum)
+		if err := w.persist(); err != nil {
+			return err
+		}
+	}
+	w.index++
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// finish flushes the last, possibly short, chunk and checks the partial write held no more chunks.
+func (w *chunkWriter) finish() error {
+	if len(w.buf) > 0 {
+		if err := w.flush(); err != nil {
+			return err
+		}
+	}
+	if w.index != len(w.manifest.Chunks) {
+		return fmt.Errorf("%w: the partial write holds %d chunks, the proving key only %d", ErrChunkMismatch, len(w.manifest.Chunks), w.index)
+	}
+	return nil
+}
+
+// ReadPkChunked restores a BN254 proving key written by WritePkResumable, checking every chunk against the
+// hash recorded in its <fn>.chunks sidecar. It fails with ErrChunkMismatch on the first corrupted chunk and
+// refuses to read a write that did not complete.
+func ReadPkChunked(fn string) (groth16.ProvingKey, error) {
+	m, err := readPkManifest(fn)
+	if err != nil {
+		return nil, err
+	}
+	if !m.Complete {
+		return nil, fmt.Errorf("proving key file %s is incomplete, resume its write with WritePkResumable", fn)
+	}
+
+	f, err := DefaultFilesystem.Open(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open proving key file %s: %w", fn, err)
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+
+	cr := &chunkReader{r: f, manifest: m}
+	var pk groth16_bn254.ProvingKey
+	if _, err := pk.ReadFrom(cr); err != nil {
+		if cr.err != nil {
+			return nil, fmt.Errorf("proving key file %s is corrupted: %w", fn, cr.err)
+		}
+		return nil, fmt.Errorf("failed to read proving key file %s: %w", fn, err)
+	}
+	// the chunks past the decoded key still have to match
+	rest, err := io.Copy(io.Discard, cr)
+	if err != nil {
+		return nil, fmt.Errorf("proving key file %s is corrupted: %w", fn, err)
+	}
+	if rest != 0 {
+		return nil, fmt.Errorf("proving key file %s holds %d trailing bytes after the key", fn, rest)
+	}
+
+	logDebug("read proving key", "file", fn, "chunks", len(m.Chunks))
+	return &pk, nil
+}
+
+// chunkReader checks every chunk of r against the manifest before serving any of its bytes.
+type chunkReader struct {
+	r        io.Reader
+	manifest *pkManifest
+	chunk    bytes.Buffer
+	index    int
+	err      error
+}
+
+// Read fills p across chunk boundaries, the proving key decoder does not handle short reads.
+func (c *chunkReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if c.err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, c.err
+		}
+		if c.chunk.Len() == 0 {
+			c.err = c.next()
+			continue
+		}
+		k, _ := c.chunk.Read(p[n:])
+		n += k
+	}
+	return n, nil
+}
+
+// next loads and checks the next chunk, returning io.EOF once the manifest is exhausted.
+func (c *chunkReader) next() error {
+	c.chunk.Reset()
+	n, err := io.CopyN(&c.chunk, c.r, c.manifest.ChunkSize)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if c.index == len(c.manifest.Chunks) {
+		if n > 0 {
+			return fmt.Errorf("%w: %d bytes past the last recorded chunk", ErrChunkMismatch, n)
+		}
+		return io.EOF
+	}
+	if n == 0 || chunkHash(c.chunk.Bytes()) != c.manifest.Chunks[c.index] {
+		return fmt.Errorf("%w: chunk %d", ErrChunkMismatch, c.index)
+	}
+	c.index++
+	return nil
+}
@@ -0,0 +1,151 @@
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+
+	"reilabs/whir-verifier-circuit/app/utilities/testutil"
+)
+
+// flakyFilesystem fails every append after the first okWrites writes, like a disk filling up.
+type flakyFilesystem struct {
+	OSFilesystem
+	okWrites int
+	writes   int
+}
+
+func (f *flakyFilesystem) OpenAppend(name string, perm fs.FileMode) (WritableFile, error) {
+	file, err := f.OSFilesystem.OpenAppend(name, perm)
+	if err != nil {
+		return nil, err
+	}
+	return &flakyFile{WritableFile: file, fsys: f}, nil
+}
+
+type flakyFile struct {
+	WritableFile
+	fsys *flakyFilesystem
+}
+
+func (f *flakyFile) Write(p []byte) (int, error) {
+	if f.fsys.writes >= f.fsys.okWrites {
+		return 0, errors.New("no space left on device")
+	}
+	f.fsys.writes++
+	return f.WritableFile.Write(p)
+}
+
+func genTestPk(t *testing.T) groth16.ProvingKey {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &testutil.SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, _, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pk
+}
+
+func Test_WritePkResumable(t *testing.T) {
+	pk := genTestPk(t)
+	var want bytes.Buffer
+	if err := WritePkTo(&want, pk); err != nil {
+		t.Fatal(err)
+	}
+	const chunkSize = 64
+	if want.Len() < 4*chunkSize {
+		t.Fatalf("test key of %d bytes is too small for the chunk size", want.Len())
+	}
+	fn := filepath.Join(t.TempDir(), "circuit.pk")
+
+	flaky := &flakyFilesystem{okWrites: 2}
+	DefaultFilesystem = flaky
+	err := WritePkResumable(pk, fn, chunkSize)
+	DefaultFilesystem = OSFilesystem{}
+	if err == nil {
+		t.Fatal("expected the interrupted write to fail")
+	}
+	partial, err := os.ReadFile(fn + partialExt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(partial) != 2*chunkSize {
+		t.Fatalf("expected two chunks to survive, got %d bytes", len(partial))
+	}
+
+	// a torn chunk past the recorded ones is dropped on resume
+	f, err := os.OpenFile(fn+partialExt, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("torn")); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	if err := WritePkResumable(pk, fn, chunkSize); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want.Bytes()) {
+		t.Fatal("expected the resumed file to match WritePk")
+	}
+	if _, err := os.Stat(fn + partialExt); !os.IsNotExist(err) {
+		t.Fatalf("expected the partial file to be renamed, got %v", err)
+	}
+
+	restored, err := ReadPkChunked(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var restoredBytes bytes.Buffer
+	if err := WritePkTo(&restoredBytes, restored); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restoredBytes.Bytes(), want.Bytes()) {
+		t.Fatal("expected ReadPkChunked to restore the key")
+	}
+
+	// corruption of any chunk is caught
+	got[3*chunkSize+1] ^= 0xff
+	if err := os.WriteFile(fn, got, SensitiveFileMode); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPkChunked(fn); !errors.Is(err, ErrChunkMismatch) {
+		t.Fatalf("expected a chunk mismatch, got %v", err)
+	}
+}
+
+func Test_WritePkResumable_OtherKey(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "circuit.pk")
+	// past the domain, which only depends on the circuit
+	DefaultFilesystem = &flakyFilesystem{okWrites: 8}
+	err := WritePkResumable(genTestPk(t), fn, 64)
+	DefaultFilesystem = OSFilesystem{}
+	if err == nil {
+		t.Fatal("expected the interrupted write to fail")
+	}
+
+	if err := WritePkResumable(genTestPk(t), fn, 64); !errors.Is(err, ErrChunkMismatch) {
+		t.Fatalf("expected resuming with another key to fail, got %v", err)
+	}
+	if err := WritePkResumable(genTestPk(t), fn, 128); !errors.Is(err, ErrChunkMismatch) {
+		t.Fatalf("expected resuming with another chunk size to fail, got %v", err)
+	}
+	if _, err := ReadPkChunked(fn); err == nil {
+		t.Fatal("expected an incomplete write to be refused")
+	}
+}
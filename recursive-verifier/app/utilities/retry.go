@@ -0,0 +1,66 @@
+package utilities
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+// retryableErrors are the errors network filesystems such as NFS or EFS report for conditions that clear up
+// on their own. Anything else, e.g. ENOSPC or EACCES, is permanent and never retried.
+var retryableErrors = []error{syscall.EAGAIN, syscall.ESTALE, syscall.EINTR}
+
+// WriteOption configures WriteAtomic and the Write* helpers that accept options.
+type WriteOption func(*writeConfig)
+
+type writeConfig struct {
+	retries  int
+	backoff  time.Duration
+	observer Observer
+	kind     string
+	verify   bool
+	progress ProgressFunc
+	// ccsHeader is set by WithCcsHeader.
+	ccsHeader bool
+}
+
+// WithRetry retries a write failing with a transient error up to retries more times, sleeping backoff
+// before the first retry and doubling it before each following one. The whole write is redone on every
+// attempt, so writeFn must be safe to call again.
+func WithRetry(retries int, backoff time.Duration) WriteOption {
+	return func(cfg *writeConfig) {
+		cfg.retries = retries
+		cfg.backoff = backoff
+	}
+}
+
+func newWriteConfig(opts []WriteOption) writeConfig {
+	cfg := writeConfig{observer: DefaultObserver, kind: "file"}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// isTransient reports whether err wraps one of retryableErrors.
+func isTransient(err error) bool {
+	for _, target := range retryableErrors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// withRetry calls attempt until it succeeds, fails with a permanent error, or cfg.retries is exhausted.
+func withRetry(cfg writeConfig, attempt func() error) error {
+	backoff := cfg.backoff
+	for i := 0; ; i++ {
+		err := attempt()
+		if err == nil || i >= cfg.retries || !isTransient(err) {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
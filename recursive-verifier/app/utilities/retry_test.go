@@ -0,0 +1,61 @@
+package utilities
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_WriteAtomic_RetriesTransientErrors(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "proof")
+
+	calls := 0
+	err := WriteAtomic(fn, func(w io.Writer) error {
+		calls++
+		if calls < 3 {
+			return fmt.Errorf("flaky mount: %w", syscall.ESTALE)
+		}
+		_, err := io.WriteString(w, "proof")
+		return err
+	}, WithRetry(3, time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 3 {
+		t.Fatalf("expected 3 attempts, got %d", calls)
+	}
+	if data, err := os.ReadFile(fn); err != nil || string(data) != "proof" {
+		t.Fatalf("unexpected content %q (%v)", data, err)
+	}
+}
+
+func Test_WriteAtomic_DoesNotRetryPermanentErrors(t *testing.T) {
+	fn := filepath.Join(t.TempDir(), "proof")
+
+	for _, opts := range [][]WriteOption{nil, {WithRetry(3, time.Millisecond)}} {
+		calls := 0
+		err := WriteAtomic(fn, func(w io.Writer) error {
+			calls++
+			return syscall.ENOSPC
+		}, opts...)
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+		if calls != 1 {
+			t.Fatalf("expected a single attempt, got %d", calls)
+		}
+	}
+
+	calls := 0
+	_ = WriteAtomic(fn, func(w io.Writer) error {
+		calls++
+		return syscall.EAGAIN
+	})
+	if calls != 1 {
+		t.Fatalf("expected no retries by default, got %d attempts", calls)
+	}
+}
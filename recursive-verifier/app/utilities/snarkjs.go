@@ -0,0 +1,127 @@
+package utilities
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// snarkjsVk mirrors the verification_key.json layout of snarkjs. Points are in projective form with
+// decimal coordinates, G1 as [x, y, "1"] and G2 as [[x.A0, x.A1], [y.A0, y.A1], ["1", "0"]].
+type snarkjsVk struct {
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+	NPublic  int        `json:"nPublic"`
+	NIC      int        `json:"nIC"`
+	Alpha1   []string   `json:"vk_alpha_1"`
+	Beta2    [][]string `json:"vk_beta_2"`
+	Gamma2   [][]string `json:"vk_gamma_2"`
+	Delta2   [][]string `json:"vk_delta_2"`
+	IC       [][]string `json:"IC"`
+}
+
+// WriteVkInJson writes a BN254 verifying key in the snarkjs verification_key.json layout, plus the length of
+// the IC array as nIC. Pedersen commitment keys have no snarkjs equivalent and are not exported.
+func WriteVkInJson(vk groth16.VerifyingKey, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteVkInJsonTo(w, vk)
+	})
+}
+
+// WriteVkInJsonTo streams the snarkjs JSON representation of vk to w.
+func WriteVkInJsonTo(w io.Writer, vk groth16.VerifyingKey) error {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return fmt.Errorf("unsupported verifying key type %T, only BN254 is supported", vk)
+	}
+
+	ic := make([][]string, len(_vk.G1.K))
+	for i := range _vk.G1.K {
+		ic[i] = g1ToSnarkjs(&_vk.G1.K[i])
+	}
+
+	vkJson, err := json.Marshal(snarkjsVk{
+		Protocol: "groth16",
+		Curve:    "bn128",
+		NPublic:  len(ic) - 1,
+		NIC:      len(ic),
+		Alpha1:   g1ToSnarkjs(&_vk.G1.Alpha),
+		Beta2:    g2ToSnarkjs(&_vk.G2.Beta),
+		Gamma2:   g2ToSnarkjs(&_vk.G2.Gamma),
+		Delta2:   g2ToSnarkjs(&_vk.G2.Delta),
+		IC:       ic,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal verifying key: %w", err)
+	}
+
+	_, err = w.Write(vkJson)
+	return err
+}
+
+// snarkjsProof mirrors the proof.json layout of snarkjs, with points encoded like in snarkjsVk.
+type snarkjsProof struct {
+	PiA      []string   `json:"pi_a"`
+	PiB      [][]string `json:"pi_b"`
+	PiC      []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+}
+
+// WriteProofInSnarkjsJson writes a BN254 proof in the snarkjs proof.json layout. Note that snarkjs keeps G2
+// coordinates as [A0, A1] in JSON, it is only its Solidity calldata that swaps them to [A1, A0], see
+// NormalizeG2ForEVM. Proofs with Pedersen commitments cannot be verified by snarkjs.
+func WriteProofInSnarkjsJson(proof groth16.Proof, fn string) error {
+	return WriteAtomic(fn, func(w io.Writer) error {
+		return WriteProofInSnarkjsJsonTo(w, proof)
+	})
+}
+
+// WriteProofInSnarkjsJsonTo streams the snarkjs JSON representation of proof to w.
+func WriteProofInSnarkjsJsonTo(w io.Writer, proof groth16.Proof) error {
+	_proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return fmt.Errorf("unsupported proof type %T, only BN254 is supported", proof)
+	}
+	if len(_proof.Commitments) > 0 {
+		return fmt.Errorf("proof has %d commitments, which snarkjs does not support", len(_proof.Commitments))
+	}
+
+	proofJson, err := json.Marshal(snarkjsProof{
+		PiA:      g1ToSnarkjs(&_proof.Ar),
+		PiB:      g2ToSnarkjs(&_proof.Bs),
+		PiC:      g1ToSnarkjs(&_proof.Krs),
+		Protocol: "groth16",
+		Curve:    "bn128",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal proof: %w", err)
+	}
+
+	_, err = w.Write(proofJson)
+	return err
+}
+
+func g1ToSnarkjs(p *bn254.G1Affine) []string {
+	return []string{fpToDecimal(&p.X), fpToDecimal(&p.Y), "1"}
+}
+
+// g2ToSnarkjs keeps gnark's (A0, A1) order, snarkjs JSON is not EVM calldata and must not be normalized.
+func g2ToSnarkjs(p *bn254.G2Affine) [][]string {
+	return [][]string{
+		{fpToDecimal(&p.X.A0), fpToDecimal(&p.X.A1)},
+		{fpToDecimal(&p.Y.A0), fpToDecimal(&p.Y.A1)},
+		{"1", "0"},
+	}
+}
+
+// fpToDecimal avoids fp.Element.String, which prints elements close to the modulus as negatives.
+func fpToDecimal(e *fp.Element) string {
+	return e.BigInt(new(big.Int)).String()
+}
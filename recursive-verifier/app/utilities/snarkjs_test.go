@@ -0,0 +1,73 @@
+package utilities
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+func Test_WriteVkInJson(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+
+	fn := filepath.Join(t.TempDir(), "verification_key.json")
+	if err := WriteVkInJson(vk, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got snarkjsVk
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Protocol != "groth16" || got.Curve != "bn128" {
+		t.Fatalf("unexpected protocol/curve %q/%q", got.Protocol, got.Curve)
+	}
+	if got.NPublic != vk.NbPublicWitness() || got.NIC != len(got.IC) || got.NIC != got.NPublic+1 {
+		t.Fatalf("unexpected counts nPublic=%d nIC=%d len(IC)=%d", got.NPublic, got.NIC, len(got.IC))
+	}
+	if len(got.Beta2) != 3 || got.Beta2[2][0] != "1" || got.Beta2[2][1] != "0" {
+		t.Fatalf("unexpected G2 layout %v", got.Beta2)
+	}
+}
+
+func Test_WriteProofInSnarkjsJson(t *testing.T) {
+	proof, _, _ := genTestProof(t)
+	_proof := proof.(*groth16_bn254.Proof)
+
+	fn := filepath.Join(t.TempDir(), "proof.json")
+	if err := WriteProofInSnarkjsJson(proof, fn); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got snarkjsProof
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Protocol != "groth16" || got.Curve != "bn128" {
+		t.Fatalf("unexpected protocol/curve %q/%q", got.Protocol, got.Curve)
+	}
+	if got.PiA[0] != fpToDecimal(&_proof.Ar.X) || got.PiA[2] != "1" {
+		t.Fatalf("unexpected pi_a %v", got.PiA)
+	}
+	if got.PiB[0][0] != fpToDecimal(&_proof.Bs.X.A0) || got.PiB[0][1] != fpToDecimal(&_proof.Bs.X.A1) {
+		t.Fatalf("expected pi_b x as [A0, A1], got %v", got.PiB[0])
+	}
+	if got.PiB[2][0] != "1" || got.PiB[2][1] != "0" {
+		t.Fatalf("unexpected pi_b z %v", got.PiB[2])
+	}
+	if got.PiC[1] != fpToDecimal(&_proof.Krs.Y) {
+		t.Fatalf("unexpected pi_c %v", got.PiC)
+	}
+}
@@ -0,0 +1,195 @@
+package utilities
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/pedersen"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+var (
+	solidityConstantPattern    = regexp.MustCompile(`(?m)^\s*uint256 constant ([A-Z0-9_]+) = (0x[0-9a-fA-F]+|[0-9]+);`)
+	solidityCommitmentsPattern = regexp.MustCompile(`uint256\[(\d+)\] memory publicCommitments;`)
+	solidityCommittedPattern   = regexp.MustCompile(`calldatacopy\(add\(publicAndCommitmentCommittedOffset, (\d+)\), add\(input, (\d+)\), (\d+)\)`)
+)
+
+// ReadVkFromSolidity reconstructs a BN254 verifying key from the constants of a Verifier.sol written by gnark's
+// ExportSolidity, as WriteVkInSolidity does, e.g. to verify proofs off-chain when only the deployed contract is left.
+// The contract does not hold G1.Beta and G1.Delta, which the verifier does not use, so they are left zero and
+// the key only serializes back to the original one up to them. At most one commitment is supported, like the
+// exporter. Contracts not laid out as gnark emits them are rejected rather than guessed at.
+func ReadVkFromSolidity(fn string) (groth16.VerifyingKey, error) {
+	contract, err := readFile(fn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read solidity verifier %s: %w", fn, err)
+	}
+
+	vk, err := parseVkFromSolidity(string(contract))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse solidity verifier %s: %w", fn, err)
+	}
+	return vk, nil
+}
+
+func parseVkFromSolidity(contract string) (*groth16_bn254.VerifyingKey, error) {
+	constants := make(map[string]*big.Int)
+	for _, m := range solidityConstantPattern.FindAllStringSubmatch(contract, -1) {
+		v, ok := parseBigInt(m[2])
+		if !ok {
+			return nil, fmt.Errorf("invalid value %q of constant %s", m[2], m[1])
+		}
+		if _, dup := constants[m[1]]; dup {
+			return nil, fmt.Errorf("constant %s is defined twice", m[1])
+		}
+		constants[m[1]] = v
+	}
+
+	coordinate := func(name string, e *fp.Element) error {
+		v, ok := constants[name]
+		if !ok {
+			return fmt.Errorf("unrecognized verifier layout: constant %s not found", name)
+		}
+		if err := checkBaseField(name, v); err != nil {
+			return err
+		}
+		e.SetBigInt(v)
+		return nil
+	}
+	g1 := func(prefix string, p *bn254.G1Affine) error {
+		if err := coordinate(prefix+"_X", &p.X); err != nil {
+			return err
+		}
+		if err := coordinate(prefix+"_Y", &p.Y); err != nil {
+			return err
+		}
+		if !p.IsInSubGroup() {
+			return fmt.Errorf("%s is not a valid G1 point", prefix)
+		}
+		return nil
+	}
+	// G2 constants are written in powers of i, _0 is the real part A0 and _1 the imaginary part A1
+	g2 := func(prefix string, p *bn254.G2Affine) error {
+		for _, c := range []struct {
+			suffix string
+			e      *fp.Element
+		}{
+			{"_X_0", &p.X.A0},
+			{"_X_1", &p.X.A1},
+			{"_Y_0", &p.Y.A0},
+			{"_Y_1", &p.Y.A1},
+		} {
+			if err := coordinate(prefix+c.suffix, c.e); err != nil {
+				return err
+			}
+		}
+		if !p.IsInSubGroup() {
+			return fmt.Errorf("%s is not a valid G2 point", prefix)
+		}
+		return nil
+	}
+
+	var vk groth16_bn254.VerifyingKey
+	if err := g1("ALPHA", &vk.G1.Alpha); err != nil {
+		return nil, err
+	}
+	// the exporter negates beta, gamma and delta to spare the negation of proof elements on chain
+	for _, p := range []struct {
+		prefix string
+		dst    *bn254.G2Affine
+	}{
+		{"BETA_NEG", &vk.G2.Beta},
+		{"GAMMA_NEG", &vk.G2.Gamma},
+		{"DELTA_NEG", &vk.G2.Delta},
+	} {
+		var neg bn254.G2Affine
+		if err := g2(p.prefix, &neg); err != nil {
+			return nil, err
+		}
+		p.dst.Neg(&neg)
+	}
+
+	vk.G1.K = make([]bn254.G1Affine, 1)
+	if err := g1("CONSTANT", &vk.G1.K[0]); err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		prefix := "PUB_" + strconv.Itoa(i)
+		if _, ok := constants[prefix+"_X"]; !ok {
+			break
+		}
+		var k bn254.G1Affine
+		if err := g1(prefix, &k); err != nil {
+			return nil, err
+		}
+		vk.G1.K = append(vk.G1.K, k)
+	}
+
+	if err := parseSolidityCommitments(contract, &vk, g2); err != nil {
+		return nil, err
+	}
+
+	if err := vk.Precompute(); err != nil {
+		return nil, fmt.Errorf("failed to precompute verifying key: %w", err)
+	}
+	return &vk, nil
+}
+
+// parseSolidityCommitments restores the Pedersen key and the committed public input indexes, which the
+// exporter only emits as the calldatacopy ranges copying the committed inputs out of the input array.
+func parseSolidityCommitments(contract string, vk *groth16_bn254.VerifyingKey, g2 func(string, *bn254.G2Affine) error) error {
+	m := solidityCommitmentsPattern.FindStringSubmatch(contract)
+	if m == nil {
+		return nil
+	}
+	nbCommitments, err := strconv.Atoi(m[1])
+	if err != nil || nbCommitments != 1 {
+		return fmt.Errorf("unsupported verifier with %s commitments, expected at most one", m[1])
+	}
+
+	var key pedersen.VerifyingKey
+	if err := g2("PEDERSEN_G", &key.G); err != nil {
+		return err
+	}
+	if err := g2("PEDERSEN_GSIGMANEG", &key.GSigmaNeg); err != nil {
+		return err
+	}
+	vk.CommitmentKeys = []pedersen.VerifyingKey{key}
+
+	// verifyProof and verifyCompressedProof each copy the committed inputs, a copy to offset 0 starts a block
+	var blocks [][]int
+	for _, c := range solidityCommittedPattern.FindAllStringSubmatch(contract, -1) {
+		dst, _ := strconv.Atoi(c[1])
+		src, _ := strconv.Atoi(c[2])
+		size, _ := strconv.Atoi(c[3])
+		if dst == 0 {
+			blocks = append(blocks, []int{})
+		}
+		if len(blocks) == 0 || dst%32 != 0 || src%32 != 0 || size%32 != 0 || dst != 32*len(blocks[len(blocks)-1]) {
+			return fmt.Errorf("unrecognized verifier layout: unexpected committed input copy %q", c[0])
+		}
+		// the input array starts after the constant one wire, which has index 0
+		for i := 0; i < size/32; i++ {
+			blocks[len(blocks)-1] = append(blocks[len(blocks)-1], src/32+1+i)
+		}
+	}
+	committed := []int{}
+	for i, block := range blocks {
+		if i > 0 && !slices.Equal(block, blocks[0]) {
+			return fmt.Errorf("unrecognized verifier layout: committed inputs %v and %v differ", blocks[0], block)
+		}
+		committed = block
+	}
+	vk.PublicAndCommitmentCommitted = [][]int{committed}
+
+	if len(vk.G1.K) <= nbCommitments {
+		return fmt.Errorf("unrecognized verifier layout: %d public input points for %d commitments", len(vk.G1.K)-1, nbCommitments)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package utilities
+
+import (
+	"bytes"
+	"flag"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func Test_WriteVkInSolidityWithOptions_Golden(t *testing.T) {
+	vk, err := ReadVk(filepath.Join("testdata", "vk.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteVkInSolidityWithOptionsTo(&buf, vk, WithPragma("0.8.19"), WithContractName("SquareVerifier"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "SquareVerifier.sol.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("exported verifier differs from %s, run with -update if the change is intended", golden)
+	}
+
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Log("solc not found, skipping compilation of the golden verifier")
+		return
+	}
+	if out, err := exec.Command(solc, "--bin", golden).CombinedOutput(); err != nil {
+		t.Fatalf("golden verifier does not compile: %v\n%s", err, out)
+	}
+}
+
+func Test_WriteVkInSolidityWithOptions_WithoutCommitmentSupport(t *testing.T) {
+	vk, err := ReadVk(filepath.Join("testdata", "vk.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = WriteVkInSolidityWithOptionsTo(&buf, vk, WithPragma("0.8.19"), WithContractName("SquareVerifier"), WithoutCommitmentSupport())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden := filepath.Join("testdata", "SquareVerifierMinimal.sol.golden")
+	if *updateGolden {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("exported verifier differs from %s, run with -update if the change is intended", golden)
+	}
+	if strings.Contains(strings.ToLower(buf.String()), "commitment") {
+		t.Fatal("expected no mention of commitments in the minimal verifier")
+	}
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &committedSquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, committedVk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVkInSolidityWithOptionsTo(&buf, committedVk, WithoutCommitmentSupport()); err == nil {
+		t.Fatal("expected a key with commitments to be rejected")
+	}
+
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Log("solc not found, skipping compilation of the minimal verifier")
+		return
+	}
+	if out, err := exec.Command(solc, "--bin", golden).CombinedOutput(); err != nil {
+		t.Fatalf("minimal verifier does not compile: %v\n%s", err, out)
+	}
+}
+
+func Test_WriteVkInSolidityWithOptions_Invalid(t *testing.T) {
+	_, vk, _ := genTestProof(t)
+
+	var buf bytes.Buffer
+	if err := WriteVkInSolidityWithOptionsTo(&buf, vk, WithPragma("0.8.19; contract X {}")); err == nil {
+		t.Fatal("expected an error for a pragma injecting code")
+	}
+	if err := WriteVkInSolidityWithOptionsTo(&buf, vk, WithContractName("Square Verifier")); err == nil {
+		t.Fatal("expected an error for an invalid contract name")
+	}
+}
+
+func Test_ReadVkFromSolidity(t *testing.T) {
+	vk, err := ReadVk(filepath.Join("testdata", "vk.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := ReadVkFromSolidity(filepath.Join("testdata", "SquareVerifier.sol.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := vk.(*groth16_bn254.VerifyingKey), recovered.(*groth16_bn254.VerifyingKey)
+	if !want.G1.Alpha.Equal(&got.G1.Alpha) || !want.G2.Beta.Equal(&got.G2.Beta) ||
+		!want.G2.Gamma.Equal(&got.G2.Gamma) || !want.G2.Delta.Equal(&got.G2.Delta) {
+		t.Fatal("recovered alpha, beta, gamma or delta differ")
+	}
+	if !reflect.DeepEqual(want.G1.K, got.G1.K) {
+		t.Fatal("recovered K points differ")
+	}
+	if len(got.CommitmentKeys) != 0 {
+		t.Fatalf("expected no commitment keys, got %d", len(got.CommitmentKeys))
+	}
+
+	dir := t.TempDir()
+	truncated := filepath.Join(dir, "Truncated.sol")
+	contract, err := os.ReadFile(filepath.Join("testdata", "SquareVerifier.sol.golden"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(truncated, []byte(strings.Replace(string(contract), "DELTA_NEG_X_1", "DELTA_X_1", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVkFromSolidity(truncated); err == nil || !strings.Contains(err.Error(), "DELTA_NEG_X_1") {
+		t.Fatalf("expected an error naming the missing constant, got %v", err)
+	}
+
+	outOfRange := filepath.Join(dir, "OutOfRange.sol")
+	alphaX := want.G1.Alpha.X.BigInt(new(big.Int)).String()
+	patched := strings.Replace(string(contract), "ALPHA_X = "+alphaX, "ALPHA_X = "+fp.Modulus().String(), 1)
+	if patched == string(contract) {
+		t.Fatal("ALPHA_X not found in the golden contract")
+	}
+	if err := os.WriteFile(outOfRange, []byte(patched), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadVkFromSolidity(outOfRange); err == nil || !strings.Contains(err.Error(), "coordinate ALPHA_X is not in the BN254 base field") {
+		t.Fatalf("expected an error naming the out of range constant, got %v", err)
+	}
+}
+
+func Test_ReadVkFromSolidity_Commitment(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fn := filepath.Join(t.TempDir(), "Verifier.sol")
+	if err := WriteVkInSolidity(vk, fn); err != nil {
+		t.Fatal(err)
+	}
+	recovered, err := ReadVkFromSolidity(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, got := vk.(*groth16_bn254.VerifyingKey), recovered.(*groth16_bn254.VerifyingKey)
+	if !reflect.DeepEqual(want.CommitmentKeys, got.CommitmentKeys) {
+		t.Fatal("recovered commitment keys differ")
+	}
+	if !reflect.DeepEqual(want.PublicAndCommitmentCommitted, got.PublicAndCommitmentCommitted) {
+		t.Fatalf("expected committed indexes %v, got %v", want.PublicAndCommitmentCommitted, got.PublicAndCommitmentCommitted)
+	}
+	if err := groth16.Verify(proof, recovered, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,149 @@
+package utilities
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// WipeSensitiveTemps makes the temporary files of sensitive artifacts be overwritten with zeros before they
+// are removed, whether by AtomicFile.Discard, a failed Close or CleanupTemps. A file is sensitive when it is
+// created without group or other permissions, as WritePk does with SensitiveFileMode. Wiping costs one more
+// write of the whole file, set it to false to trade that for speed.
+//
+// Wiping is a best effort, not a secure erase. Journaling and copy-on-write filesystems, snapshots and the
+// wear levelling of SSDs may all keep copies of the original blocks, and only filesystems implementing
+// Wipe, such as OSFilesystem, are wiped at all. Encrypt the disk if setup material must not be recoverable.
+var WipeSensitiveTemps = true
+
+// TempFileTemplate names the temporary file an atomic write of a file goes through, in the directory of the
+// file, with every "{name}" replaced by the base name of the file, e.g. "{name}.tmp". It is empty by default,
+// which gives temporary files a random suffix. A fixed name is meant for reproducible build sandboxes that
+// snapshot the whole output tree: it gives up the safety of concurrent writes, two writes of the same file
+// share one temporary file and clobber each other, and a temporary file left behind by a crash is
+// silently overwritten by the next write.
+var TempFileTemplate = ""
+
+// createTargetTemp creates the temporary file of an atomic write of file, as TempFileTemplate names it.
+func createTargetTemp(fsys Filesystem, file string, perm fs.FileMode) (WritableFile, string, error) {
+	dir, base := filepath.Dir(file), filepath.Base(file)
+	if TempFileTemplate == "" {
+		return createTemp(fsys, dir, base+".tmp-", perm)
+	}
+	if !strings.Contains(TempFileTemplate, "{name}") || strings.ContainsRune(TempFileTemplate, filepath.Separator) {
+		return nil, "", fmt.Errorf("invalid TempFileTemplate %q: it must contain {name} and no path separator", TempFileTemplate)
+	}
+	name := filepath.Join(dir, strings.ReplaceAll(TempFileTemplate, "{name}", base))
+	if name == filepath.Join(dir, base) {
+		return nil, "", fmt.Errorf("invalid TempFileTemplate %q: it names the file itself", TempFileTemplate)
+	}
+	// remove what a crashed write left behind rather than open it, it may be a planted symlink
+	if err := fsys.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", err
+	}
+	f, err := fsys.CreateExclusive(name, perm)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, name, nil
+}
+
+// wipeFilesystem is implemented by filesystems able to overwrite a file in place.
+type wipeFilesystem interface {
+	Wipe(name string) error
+}
+
+// Wipe overwrites the content of name with zeros and syncs it, keeping its size.
+func (OSFilesystem) Wipe(name string) error {
+	f, err := os.OpenFile(name, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+	if _, err := io.CopyN(f, zeroReader{}, info.Size()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+func isSensitiveMode(mode fs.FileMode) bool {
+	return mode.Perm()&0o077 == 0
+}
+
+// liveTemps holds every AtomicFile whose temporary file is neither renamed nor removed yet.
+var liveTemps = struct {
+	sync.Mutex
+	files map[*AtomicFile]struct{}
+}{files: make(map[*AtomicFile]struct{})}
+
+func registerTemp(f *AtomicFile) {
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+	liveTemps.files[f] = struct{}{}
+}
+
+func unregisterTemp(f *AtomicFile) {
+	liveTemps.Lock()
+	defer liveTemps.Unlock()
+	delete(liveTemps.files, f)
+}
+
+// removeTemp removes the temporary file of f, wiping it first if it is sensitive.
+func removeTemp(f *AtomicFile) error {
+	unregisterTemp(f)
+	wiped := false
+	if f.sensitive && WipeSensitiveTemps {
+		if w, ok := f.fsys.(wipeFilesystem); ok {
+			wiped = w.Wipe(f.tmpName) == nil
+		}
+	}
+	if err := f.fsys.Remove(f.tmpName); err != nil {
+		return err
+	}
+	logDebug("removed temporary file", "file", f.tmpName, "target", f.target, "wiped", wiped)
+	return nil
+}
+
+// CleanupTemps removes the temporary files of every write still in progress, wiping sensitive ones as
+// WipeSensitiveTemps says, and returns the names it failed to remove. Call it from a signal handler or on
+// the way out of a failed run, so that an interrupted WritePk does not leave setup material on disk. The
+// interrupted writes then fail on Close and leave their targets untouched. Nothing can clean up after
+// SIGKILL or a crash, the next run finds the files named after their target followed by .tmp-, or as
+// TempFileTemplate names them.
+func CleanupTemps() error {
+	liveTemps.Lock()
+	files := make([]*AtomicFile, 0, len(liveTemps.files))
+	for f := range liveTemps.files {
+		files = append(files, f)
+	}
+	liveTemps.Unlock()
+
+	var errs []error
+	for _, f := range files {
+		if err := removeTemp(f); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to remove temporary file %s: %w", f.tmpName, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,149 @@
+package utilities
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func Test_CleanupTemps(t *testing.T) {
+	dir := t.TempDir()
+	secret := []byte("toxic waste")
+
+	pk, err := OpenFileOnCreateOrOverwriteWithMode(filepath.Join(dir, "circuit.pk"), SensitiveFileMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pk.Write(secret); err != nil {
+		t.Fatal(err)
+	}
+	if err := pk.File.Sync(); err != nil {
+		t.Fatal(err)
+	}
+	public, err := OpenFileOnCreateOrOverwrite(filepath.Join(dir, "circuit.vk"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := public.Write(secret); err != nil {
+		t.Fatal(err)
+	}
+
+	// a hard link keeps the content of the sensitive temporary file observable once it is removed
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Link(pk.tmpName, link); err != nil {
+		t.Skipf("hard links not supported: %v", err)
+	}
+
+	if err := CleanupTemps(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected every temporary file to be removed, found %s", entries[0].Name())
+	}
+	wiped, err := os.ReadFile(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wiped, make([]byte, len(secret))) {
+		t.Fatalf("expected the sensitive temporary file to be zeroed, got %q", wiped)
+	}
+
+	if err := pk.Close(); err == nil || !strings.Contains(err.Error(), "circuit.pk") {
+		t.Fatalf("expected the interrupted write to fail, got %v", err)
+	}
+	_ = public.Discard()
+	if _, err := os.Stat(filepath.Join(dir, "circuit.pk")); !os.IsNotExist(err) {
+		t.Fatalf("expected the target not to be written, got %v", err)
+	}
+}
+
+func Test_AtomicFile_UnregistersOnClose(t *testing.T) {
+	f, err := OpenFileOnCreateOrOverwrite(filepath.Join(t.TempDir(), "proof"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	liveTemps.Lock()
+	_, live := liveTemps.files[f]
+	liveTemps.Unlock()
+	if live {
+		t.Fatal("expected a closed file to be unregistered")
+	}
+}
+
+func Test_TempFileTemplate(t *testing.T) {
+	defer func() { TempFileTemplate = "" }()
+	TempFileTemplate = "{name}.tmp"
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "circuit.vk")
+
+	f, err := OpenFileOnCreateOrOverwrite(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(fn + ".tmp"); err != nil {
+		t.Fatalf("expected the temporary file to be named after the template: %v", err)
+	}
+	if _, err := f.Write([]byte("vk")); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a temporary file left behind by a crash is overwritten
+	if err := os.WriteFile(fn+".tmp", []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAtomic(fn, func(w io.Writer) error {
+		_, err := w.Write([]byte("new vk"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "circuit.vk" {
+		t.Fatalf("expected only the target to remain, got %v", entries)
+	}
+	if data, _ := os.ReadFile(fn); string(data) != "new vk" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	// a symlink planted at the temporary name is replaced, not written through
+	victim := filepath.Join(t.TempDir(), "victim")
+	if err := os.WriteFile(victim, []byte("victim"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(victim, fn+".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteAtomic(fn, func(w io.Writer) error {
+		_, err := w.Write([]byte("vk"))
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(victim); string(data) != "victim" {
+		t.Fatalf("expected the symlink target to be untouched, got %q", data)
+	}
+
+	for _, template := range []string{"fixed.tmp", "{name}", "tmp/{name}"} {
+		TempFileTemplate = template
+		if _, err := OpenFileOnCreateOrOverwrite(fn); err == nil {
+			t.Fatalf("expected template %q to be rejected", template)
+		}
+	}
+}
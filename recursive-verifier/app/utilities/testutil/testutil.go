@@ -0,0 +1,60 @@
+// Package testutil provides proof fixtures for tests, so they do not depend on artifacts committed to the repository.
+package testutil
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// SquareCircuit proves knowledge of a secret X whose square is the public Y.
+type SquareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (c *SquareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(c.X, c.X), c.Y)
+	return nil
+}
+
+// GenTestProof compiles SquareCircuit, runs a fresh groth16 setup and proves 3*3 == 9 on BN254. Every call
+// runs its own setup, so the keys of two calls differ.
+func GenTestProof(t testing.TB) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+	return GenTestProofOnCurve(t, ecc.BN254)
+}
+
+// GenTestProofOnCurve is GenTestProof on the given curve.
+func GenTestProofOnCurve(t testing.TB, curveID ecc.ID) (groth16.Proof, groth16.VerifyingKey, witness.Witness) {
+	t.Helper()
+
+	ccs, err := frontend.Compile(curveID.ScalarField(), r1cs.NewBuilder, &SquareCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fullWitness, err := frontend.NewWitness(&SquareCircuit{X: 3, Y: 9}, curveID.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return proof, vk, publicWitness
+}
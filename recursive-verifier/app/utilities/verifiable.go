@@ -0,0 +1,128 @@
+package utilities
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+)
+
+// PackageHeaderSize is the length of the header of the files written by WriteVerifiablePackage.
+const PackageHeaderSize = 7
+
+var packageMagic = []byte("gvpk")
+
+const packageVersion = 1
+
+// WriteVerifiablePackage writes proof, the public part of publicWitness and the VkHash of the verifying key
+// they verify against into fn, binding the three in one file for archival. The file starts with a
+// PackageHeaderSize byte header: the magic "gvpk", the format version 1 and the ecc.ID of the proof as a
+// big-endian uint16. Three frames follow in the WriteProofBatch framing, each prefixed with its length as a
+// big-endian uint32: the 32 byte vkHash, the proof in gnark's binary serialization and the public witness in
+// gnark's binary witness encoding. The key itself is not stored: archive it separately, ReadVerifiablePackage
+// returns the hash to look it up by and to check it against. Only BN254 proofs are supported, as VkHash is.
+func WriteVerifiablePackage(proof groth16.Proof, publicWitness witness.Witness, vkHash [32]byte, fn string) error {
+	if proof.CurveID() != ecc.BN254 {
+		return fmt.Errorf("verifiable packages are only supported on %s, got %s", ecc.BN254, proof.CurveID())
+	}
+	var proofBuf bytes.Buffer
+	if _, err := proof.WriteTo(&proofBuf); err != nil {
+		return fmt.Errorf("failed to serialize proof: %w", err)
+	}
+	pw, err := PublicOnly(publicWitness)
+	if err != nil {
+		return err
+	}
+	witnessData, err := pw.MarshalBinary()
+	if err != nil {
+		return fmt.Errorf("failed to serialize public witness: %w", err)
+	}
+
+	return WriteAtomic(fn, func(w io.Writer) error {
+		header := make([]byte, PackageHeaderSize)
+		copy(header, packageMagic)
+		header[4] = packageVersion
+		binary.BigEndian.PutUint16(header[5:], uint16(proof.CurveID()))
+		if _, err := w.Write(header); err != nil {
+			return err
+		}
+		for _, frame := range [][]byte{vkHash[:], proofBuf.Bytes(), witnessData} {
+			if err := writeFrame(w, frame); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ReadVerifiablePackage restores the proof, public witness and verifying key hash written by
+// WriteVerifiablePackage. It rejects other format versions and trailing bytes. Verify the proof only against
+// a key whose VkHash equals the returned hash.
+func ReadVerifiablePackage(fn string) (groth16.Proof, witness.Witness, [32]byte, error) {
+	data, err := readFile(fn)
+	if err != nil {
+		return nil, nil, [32]byte{}, fmt.Errorf("failed to read package file %s: %w", fn, err)
+	}
+	proof, pw, vkHash, err := parseVerifiablePackage(data)
+	if err != nil {
+		return nil, nil, [32]byte{}, fmt.Errorf("failed to read package file %s: %w", fn, err)
+	}
+	return proof, pw, vkHash, nil
+}
+
+func parseVerifiablePackage(data []byte) (groth16.Proof, witness.Witness, [32]byte, error) {
+	var vkHash [32]byte
+	if !bytes.HasPrefix(data, packageMagic) {
+		return nil, nil, vkHash, errors.New("not a verifiable package: missing magic")
+	}
+	if len(data) < PackageHeaderSize {
+		return nil, nil, vkHash, fmt.Errorf("truncated package header of %d bytes: %w", len(data), io.ErrUnexpectedEOF)
+	}
+	if version := data[4]; version != packageVersion {
+		return nil, nil, vkHash, fmt.Errorf("unsupported package format version %d", version)
+	}
+	curveID := ecc.ID(binary.BigEndian.Uint16(data[5:]))
+	if curveID != ecc.BN254 {
+		return nil, nil, vkHash, fmt.Errorf("package holds a proof on %s, expected %s", curveID, ecc.BN254)
+	}
+
+	// data is already in memory, frames are cut from it rather than copied out with readFrame
+	rest := data[PackageHeaderSize:]
+	var frames [3][]byte
+	for i := range frames {
+		if len(rest) < frameHeaderLen {
+			return nil, nil, vkHash, fmt.Errorf("truncated frame header: %w", io.ErrUnexpectedEOF)
+		}
+		n := binary.BigEndian.Uint32(rest)
+		rest = rest[frameHeaderLen:]
+		if uint64(n) > uint64(len(rest)) {
+			return nil, nil, vkHash, fmt.Errorf("truncated frame of %d bytes: %w", n, io.ErrUnexpectedEOF)
+		}
+		frames[i], rest = rest[:n], rest[n:]
+	}
+	if len(rest) > 0 {
+		return nil, nil, vkHash, fmt.Errorf("package holds %d trailing bytes", len(rest))
+	}
+
+	if len(frames[0]) != len(vkHash) {
+		return nil, nil, vkHash, fmt.Errorf("verifying key hash frame holds %d bytes, expected %d", len(frames[0]), len(vkHash))
+	}
+	copy(vkHash[:], frames[0])
+	proof, err := unmarshalProof(frames[1], curveID)
+	if err != nil {
+		return nil, nil, vkHash, fmt.Errorf("failed to decode proof: %w", err)
+	}
+	pw, err := witness.New(curveID.ScalarField())
+	if err != nil {
+		return nil, nil, vkHash, fmt.Errorf("failed to create witness: %w", err)
+	}
+	if err := pw.UnmarshalBinary(frames[2]); err != nil {
+		return nil, nil, vkHash, fmt.Errorf("failed to decode public witness: %w", err)
+	}
+	return proof, pw, vkHash, nil
+}
@@ -0,0 +1,77 @@
+package utilities
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+func Test_VerifiablePackage(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	vkHash, err := VkHash(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	fn := filepath.Join(dir, "proof.vpk")
+	if err := WriteVerifiablePackage(proof, publicWitness, vkHash, fn); err != nil {
+		t.Fatal(err)
+	}
+	restoredProof, restoredWitness, restoredHash, err := ReadVerifiablePackage(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restoredHash != vkHash {
+		t.Fatalf("expected vk hash %x, got %x", vkHash, restoredHash)
+	}
+	if !restoredProof.(*groth16_bn254.Proof).Krs.Equal(&proof.(*groth16_bn254.Proof).Krs) {
+		t.Fatal("package round trip changed the proof")
+	}
+	if err := VerifyLoadedProof(restoredProof, vk, restoredWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name string, data []byte) string {
+		fn := filepath.Join(dir, name)
+		if err := os.WriteFile(fn, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return fn
+	}
+
+	newer := append([]byte(nil), data...)
+	newer[4] = packageVersion + 1
+	if _, _, _, err := ReadVerifiablePackage(write("newer.vpk", newer)); err == nil {
+		t.Fatal("expected an unknown format version to be rejected")
+	}
+	if _, _, _, err := ReadVerifiablePackage(write("trailing.vpk", append(append([]byte(nil), data...), 0))); err == nil {
+		t.Fatal("expected trailing bytes to be rejected")
+	}
+	if _, _, _, err := ReadVerifiablePackage(write("truncated.vpk", data[:len(data)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a truncated package to be rejected, got %v", err)
+	}
+	huge := append([]byte(nil), data...)
+	binary.BigEndian.PutUint32(huge[PackageHeaderSize:], 1<<31)
+	if _, _, _, err := ReadVerifiablePackage(write("huge.vpk", huge)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected a frame longer than the file to be rejected, got %v", err)
+	}
+	if _, _, _, err := ReadVerifiablePackage(write("raw.proof", data[PackageHeaderSize:])); err == nil {
+		t.Fatal("expected a file without the magic to be rejected")
+	}
+
+	bls, _, blsWitness := genTestProofOnCurve(t, ecc.BLS12_381)
+	if err := WriteVerifiablePackage(bls, blsWitness, vkHash, filepath.Join(dir, "bls.vpk")); err == nil {
+		t.Fatal("expected a BLS12-381 proof to be rejected")
+	}
+}
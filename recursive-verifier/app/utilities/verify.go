@@ -0,0 +1,259 @@
+package utilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"slices"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/hash_to_field"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"golang.org/x/crypto/sha3"
+)
+
+// VerifyProof loads a binary BN254 proof, a binary verifying key and a JSON public witness, as written by
+// WriteProof, WriteVk and WritePublicWitnessInJson, and runs groth16.Verify on them.
+func VerifyProof(proofFn, vkFn, witnessFn string) error {
+	proof, err := ReadProof(proofFn)
+	if err != nil {
+		return fmt.Errorf("failed to load proof: %w", err)
+	}
+
+	vk, err := ReadVk(vkFn)
+	if err != nil {
+		return fmt.Errorf("failed to load verifying key: %w", err)
+	}
+
+	publicWitness, err := ReadPublicWitness(witnessFn, ecc.BN254)
+	if err != nil {
+		return fmt.Errorf("failed to load public witness: %w", err)
+	}
+
+	return VerifyLoadedProof(proof, vk, publicWitness)
+}
+
+// MaxStreamedProofSize is the limit VerifyProofReader reads a proof with, far above the size of any BN254
+// proof but low enough that a misdirected stream is rejected early.
+const MaxStreamedProofSize int64 = 1 << 20
+
+// VerifyProofReader is VerifyProof decoding the binary proof from r, e.g. stdin, with ReadProofFrom limited to
+// MaxStreamedProofSize bytes. r must hold nothing but the proof, trailing bytes are rejected.
+func VerifyProofReader(r io.Reader, vkFn, witnessFn string) error {
+	proof, err := ReadProofFrom(r, MaxStreamedProofSize)
+	if err != nil {
+		return fmt.Errorf("failed to load proof: %w", err)
+	}
+	if n, _ := io.ReadFull(r, make([]byte, 1)); n > 0 {
+		return errors.New("failed to load proof: trailing bytes after the proof")
+	}
+
+	vk, err := ReadVk(vkFn)
+	if err != nil {
+		return fmt.Errorf("failed to load verifying key: %w", err)
+	}
+
+	publicWitness, err := ReadPublicWitness(witnessFn, ecc.BN254)
+	if err != nil {
+		return fmt.Errorf("failed to load public witness: %w", err)
+	}
+
+	return VerifyLoadedProof(proof, vk, publicWitness)
+}
+
+// VerifyFromCalldata verifies the arguments of a call to the verifyProof function of the exported verifier
+// contract, as WriteProofInSolidity and WriteVerifyCalldata emit them, against the BN254 verifying key in
+// vkFn, to reproduce a reverted call off-chain. commitments and pok are the commitment arguments of a circuit
+// with commitments, leave both empty otherwise. input excludes the inputs derived from commitments, as the
+// contract computes them itself with keccak256: as for the contract, a proof generated without
+// solidity.WithProverTargetSolidityVerifier is rejected, see VerifyLoadedProofForSolidity. Every word must be
+// canonical, as the contract requires.
+func VerifyFromCalldata(vkFn string, proofWords [ProofWordCount]*big.Int, commitments, pok []*big.Int, input []*big.Int) error {
+	vk, err := ReadVk(vkFn)
+	if err != nil {
+		return fmt.Errorf("failed to load verifying key: %w", err)
+	}
+
+	if len(commitments) == 0 && len(pok) == 0 {
+		pok = []*big.Int{new(big.Int), new(big.Int)}
+	}
+	if err := checkSolidityWords(proofWords[:], commitments, pok); err != nil {
+		return fmt.Errorf("invalid calldata: %w", err)
+	}
+	proof, err := proofFromSolidityWords(proofWords[:], commitments, pok)
+	if err != nil {
+		return fmt.Errorf("invalid proof in calldata: %w", err)
+	}
+
+	publicWitness, err := newPublicWitness(ecc.BN254, input)
+	if err != nil {
+		return fmt.Errorf("invalid input in calldata: %w", err)
+	}
+	if err := CheckPublicInputCount(vk, publicWitness); err != nil {
+		return err
+	}
+
+	return VerifyLoadedProofForSolidity(proof, vk, publicWitness)
+}
+
+// VerifyLoadedProof runs groth16.Verify, wrapping the gnark error on mismatch.
+//
+// The public inputs a BN254 circuit derives from its commitments are not part of the public witness, gnark
+// recomputes them from the proof commitments with its default hash-to-field. A proof generated for the
+// exported verifier contract derives them with keccak256 instead, see CommitmentPublicInputs, and is rejected:
+// verify it with VerifyLoadedProofForSolidity, or with VerifyLoadedProofAnyHash when its target is unknown. A
+// public witness that also holds the derived inputs after the circuit inputs, as the full K vector of the key
+// expects, is accepted as well: they are checked against the values recomputed from the proof and dropped
+// before verifying.
+func VerifyLoadedProof(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) error {
+	return verifyLoadedProof(proof, vk, publicWitness, defaultCommitmentHash)
+}
+
+// VerifyLoadedProofForSolidity is VerifyLoadedProof deriving the commitment public inputs with keccak256, as
+// the exported verifier contract does, so it accepts exactly the proofs the contract accepts.
+func VerifyLoadedProofForSolidity(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) error {
+	return verifyLoadedProof(proof, vk, publicWitness, solidityCommitmentHash)
+}
+
+// VerifyLoadedProofAnyHash is VerifyLoadedProof accepting a commitment proof whose public inputs were derived
+// with either gnark's default hash-to-field or keccak256, for proofs of unknown target. A proof it accepts
+// may still be rejected by the verifier contract: never use it to decide what to submit on chain.
+func VerifyLoadedProofAnyHash(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness) error {
+	return verifyLoadedProof(proof, vk, publicWitness, defaultCommitmentHash, solidityCommitmentHash)
+}
+
+// verifyLoadedProof verifies proof with each of hashes until one succeeds. Proofs without commitments do not
+// depend on the hash and are verified once.
+func verifyLoadedProof(proof groth16.Proof, vk groth16.VerifyingKey, publicWitness witness.Witness, hashes ...commitmentHash) error {
+	_proof, okProof := proof.(*groth16_bn254.Proof)
+	_vk, okVk := vk.(*groth16_bn254.VerifyingKey)
+	if !okProof || !okVk || len(_proof.Commitments) == 0 {
+		if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+			return fmt.Errorf("proof verification failed: %w", err)
+		}
+		return nil
+	}
+
+	publicWitness, hashes, err := stripCommitmentPublicInputs(_proof, _vk, publicWitness, hashes)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, h := range hashes {
+		err := groth16.Verify(proof, vk, publicWitness, h.option())
+		if err == nil {
+			logDebug("verified commitment proof", "hash", h.name)
+			return nil
+		}
+		errs = append(errs, fmt.Errorf("with the %s hash-to-field: %w", h.name, err))
+	}
+	return fmt.Errorf("proof verification failed: %w", errors.Join(errs...))
+}
+
+// commitmentHash is a hash-to-field gnark may derive the commitment public inputs with.
+type commitmentHash struct {
+	name   string
+	new    func() hash.Hash
+	option func() backend.VerifierOption
+}
+
+var (
+	defaultCommitmentHash = commitmentHash{
+		name: "default",
+		new:  func() hash.Hash { return hash_to_field.New([]byte(constraint.CommitmentDst)) },
+		option: func() backend.VerifierOption {
+			return backend.WithVerifierHashToFieldFunction(hash_to_field.New([]byte(constraint.CommitmentDst)))
+		},
+	}
+	solidityCommitmentHash = commitmentHash{
+		name:   "keccak256",
+		new:    func() hash.Hash { return sha3.NewLegacyKeccak256() },
+		option: func() backend.VerifierOption { return solidity.WithVerifierTargetSolidityVerifier(backend.GROTH16) },
+	}
+)
+
+// stripCommitmentPublicInputs returns publicWitness without the commitment-derived inputs it may hold after
+// the circuit inputs, together with the hashes to verify the proof with: the one of hashes matching the
+// dropped inputs or, if publicWitness holds none of them, all of hashes.
+func stripCommitmentPublicInputs(proof *groth16_bn254.Proof, vk *groth16_bn254.VerifyingKey, publicWitness witness.Witness, hashes []commitmentHash) (witness.Witness, []commitmentHash, error) {
+	pw, err := PublicOnly(publicWitness)
+	if err != nil {
+		return nil, nil, err
+	}
+	values, err := witnessToBigInts(pw)
+	if err != nil {
+		return nil, nil, err
+	}
+	nbInputs := PublicInputCount(vk)
+	if len(values) != nbInputs+len(vk.PublicAndCommitmentCommitted) {
+		return pw, hashes, nil
+	}
+
+	stripped, err := newPublicWitness(ecc.BN254, values[:nbInputs])
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, h := range hashes {
+		derived, err := commitmentPublicInputs(proof, vk, stripped, h.new())
+		if err != nil {
+			return nil, nil, err
+		}
+		if slices.EqualFunc(derived, values[nbInputs:], func(a, b *big.Int) bool { return a.Cmp(b) == 0 }) {
+			return stripped, []commitmentHash{h}, nil
+		}
+	}
+	return nil, nil, errors.New("proof verification failed: the commitment public inputs of the witness do not match the proof commitments")
+}
+
+// ProofWitnessPair is a proof together with the public witness it is verified against.
+type ProofWitnessPair struct {
+	Proof         groth16.Proof
+	PublicWitness witness.Witness
+}
+
+// VerifyBatch verifies every pair against vk with at most parallelism concurrent verifications, or
+// runtime.GOMAXPROCS(0) when parallelism <= 0. The i-th error belongs to pairs[i], nil meaning the proof is valid.
+func VerifyBatch(vk groth16.VerifyingKey, pairs []ProofWitnessPair, parallelism int) []error {
+	return VerifyBatchCtx(context.Background(), vk, pairs, parallelism)
+}
+
+// PublicInputCount returns the number of public inputs a BN254 vk expects, i.e. the length of the input
+// array of the exported verifier contract: len(G1.K)-1 minus the inputs derived from commitments, which
+// the verifier computes itself. It returns -1 for keys of other curves.
+func PublicInputCount(vk groth16.VerifyingKey) int {
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return -1
+	}
+	return len(_vk.G1.K) - 1 - len(_vk.PublicAndCommitmentCommitted)
+}
+
+// CheckPublicInputCount returns an error if the public part of publicWitness does not hold exactly
+// PublicInputCount(vk) inputs.
+func CheckPublicInputCount(vk groth16.VerifyingKey, publicWitness witness.Witness) error {
+	want := PublicInputCount(vk)
+	if want < 0 {
+		return fmt.Errorf("unsupported verifying key type %T, only BN254 is supported", vk)
+	}
+
+	pw, err := PublicOnly(publicWitness)
+	if err != nil {
+		return err
+	}
+	values, err := witnessToBigInts(pw)
+	if err != nil {
+		return err
+	}
+	if len(values) != want {
+		return fmt.Errorf("public witness holds %d inputs, verifying key expects %d", len(values), want)
+	}
+	return nil
+}
@@ -0,0 +1,326 @@
+package utilities
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/solidity"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+func Test_VerifyProof(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+
+	proofFn := filepath.Join(dir, "proof")
+	vkFn := filepath.Join(dir, "vk")
+	witnessFn := filepath.Join(dir, "pub_in.json")
+	if err := WriteProof(proof, proofFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteVk(vk, vkFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePublicWitnessInJson(publicWitness, witnessFn); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyProof(proofFn, vkFn, witnessFn); err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherVk, _ := genTestProof(t)
+	if err := VerifyLoadedProof(proof, otherVk, publicWitness); err == nil {
+		t.Fatal("expected verification against a different key to fail")
+	}
+}
+
+func Test_VerifyProofCommitments(t *testing.T) {
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	vkFn := filepath.Join(dir, "vk")
+	witnessFn := filepath.Join(dir, "pub_in.json")
+	if err := WriteVk(vk, vkFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePublicWitnessInJson(publicWitness, witnessFn); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		opts   []backend.ProverOption
+		hash   commitmentHash
+		verify func(groth16.Proof, groth16.VerifyingKey, witness.Witness) error
+		other  func(groth16.Proof, groth16.VerifyingKey, witness.Witness) error
+	}{
+		{"default", nil, defaultCommitmentHash, VerifyLoadedProof, VerifyLoadedProofForSolidity},
+		{"solidity", []backend.ProverOption{solidity.WithProverTargetSolidityVerifier(backend.GROTH16)}, solidityCommitmentHash, VerifyLoadedProofForSolidity, VerifyLoadedProof},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			proof, err := groth16.Prove(ccs, pk, fullWitness, tc.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proofFn := filepath.Join(dir, tc.name+".proof")
+			if err := WriteProof(proof, proofFn); err != nil {
+				t.Fatal(err)
+			}
+			err = VerifyProof(proofFn, vkFn, witnessFn)
+			if tc.name == "default" && err != nil {
+				t.Fatal(err)
+			}
+			if tc.name == "solidity" && err == nil {
+				t.Fatal("expected VerifyProof to reject a proof derived with keccak256")
+			}
+			if err := tc.verify(proof, vk, publicWitness); err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.other(proof, vk, publicWitness); err == nil {
+				t.Fatal("expected the proof to be rejected with the other hash-to-field")
+			}
+			if err := VerifyLoadedProofAnyHash(proof, vk, publicWitness); err != nil {
+				t.Fatal(err)
+			}
+
+			// a witness holding the derived inputs too, as the K vector of the key lists them
+			derived, err := commitmentPublicInputs(proof, vk, publicWitness, tc.hash.new())
+			if err != nil {
+				t.Fatal(err)
+			}
+			extended, err := newPublicWitness(ecc.BN254, append([]*big.Int{big.NewInt(9)}, derived...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := tc.verify(proof, vk, extended); err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyLoadedProofAnyHash(proof, vk, extended); err != nil {
+				t.Fatal(err)
+			}
+
+			tampered, err := newPublicWitness(ecc.BN254, []*big.Int{big.NewInt(9), new(big.Int).Add(derived[0], big.NewInt(1))})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyLoadedProofAnyHash(proof, vk, tampered); err == nil {
+				t.Fatal("expected a tampered commitment input to be rejected")
+			}
+			wrong, err := newPublicWitness(ecc.BN254, []*big.Int{big.NewInt(16)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := VerifyLoadedProofAnyHash(proof, vk, wrong); err == nil {
+				t.Fatal("expected a wrong public input to be rejected")
+			}
+		})
+	}
+}
+
+func Test_VerifyProofReader(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	dir := t.TempDir()
+
+	vkFn := filepath.Join(dir, "vk")
+	witnessFn := filepath.Join(dir, "pub_in.json")
+	if err := WriteVk(vk, vkFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := WritePublicWitnessInJson(publicWitness, witnessFn); err != nil {
+		t.Fatal(err)
+	}
+	data, err := MarshalProof(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyProofReader(bytes.NewReader(data), vkFn, witnessFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProofReader(bytes.NewReader(append(proofHeader(ecc.BN254), data...)), vkFn, witnessFn); err != nil {
+		t.Fatal(err)
+	}
+
+	_, otherVk, _ := genTestProof(t)
+	otherVkFn := filepath.Join(dir, "other.vk")
+	if err := WriteVk(otherVk, otherVkFn); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyProofReader(bytes.NewReader(data), otherVkFn, witnessFn); err == nil {
+		t.Fatal("expected verification against a different key to fail")
+	}
+	if err := VerifyProofReader(bytes.NewReader(append(data, 0)), vkFn, witnessFn); err == nil {
+		t.Fatal("expected trailing bytes to be rejected")
+	}
+	if err := VerifyProofReader(bytes.NewReader(data[:len(data)-1]), vkFn, witnessFn); err == nil {
+		t.Fatal("expected a truncated proof to be rejected")
+	}
+}
+
+func Test_VerifyBatch(t *testing.T) {
+	proof, vk, publicWitness := genTestProof(t)
+	otherProof, _, _ := genTestProof(t)
+
+	pairs := make([]ProofWitnessPair, 9)
+	for i := range pairs {
+		pairs[i] = ProofWitnessPair{Proof: proof, PublicWitness: publicWitness}
+	}
+	pairs[4].Proof = otherProof
+
+	for _, parallelism := range []int{0, 1, 3, 100} {
+		errs := VerifyBatch(vk, pairs, parallelism)
+		if len(errs) != len(pairs) {
+			t.Fatalf("expected %d results, got %d", len(pairs), len(errs))
+		}
+		for i, err := range errs {
+			if i == 4 && err == nil {
+				t.Fatalf("parallelism %d: expected the foreign proof to fail", parallelism)
+			}
+			if i != 4 && err != nil {
+				t.Fatalf("parallelism %d: pair %d: %v", parallelism, i, err)
+			}
+		}
+	}
+
+	if errs := VerifyBatch(vk, nil, 4); len(errs) != 0 {
+		t.Fatalf("expected no results for no pairs, got %v", errs)
+	}
+}
+
+func Test_PublicInputCount(t *testing.T) {
+	_, vk, publicWitness := genTestProof(t)
+	if n := PublicInputCount(vk); n != 1 {
+		t.Fatalf("expected 1 public input, got %d", n)
+	}
+	if err := CheckPublicInputCount(vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := ConcatPublicWitnesses([]witness.Witness{publicWitness, publicWitness})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckPublicInputCount(vk, merged); err == nil {
+		t.Fatal("expected a witness with too many inputs to be rejected")
+	}
+
+	_, blsVk, _ := genTestProofOnCurve(t, ecc.BLS12_381)
+	if n := PublicInputCount(blsVk); n != -1 {
+		t.Fatalf("expected -1 for a BLS12-381 key, got %d", n)
+	}
+}
+
+func Test_VerifyFromCalldata(t *testing.T) {
+	plain, plainVk, plainWitness := genTestProof(t)
+
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &publicCommitCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, committedVk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fullWitness, err := frontend.NewWitness(&publicCommitCircuit{X: 3, Y: 9}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	committedWitness, err := fullWitness.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := groth16.Prove(ccs, pk, fullWitness, solidity.WithProverTargetSolidityVerifier(backend.GROTH16))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name          string
+		proof         groth16.Proof
+		vk            groth16.VerifyingKey
+		publicWitness witness.Witness
+	}{
+		{"plain", plain, plainVk, plainWitness},
+		{"committed", committed, committedVk, committedWitness},
+	} {
+		vkFn := filepath.Join(dir, tc.name+".vk")
+		if err := WriteVk(tc.vk, vkFn); err != nil {
+			t.Fatal(err)
+		}
+		proofWords, commitments, pok, err := proofToSolidityWords(tc.proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input, err := witnessToBigInts(tc.publicWitness)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var pokWords []*big.Int
+		if len(commitments) > 0 {
+			pokWords = pok[:]
+		}
+
+		if err := VerifyFromCalldata(vkFn, proofWords, commitments, pokWords, input); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		wrongInput := []*big.Int{new(big.Int).Add(input[0], big.NewInt(1))}
+		if err := VerifyFromCalldata(vkFn, proofWords, commitments, pokWords, wrongInput); err == nil {
+			t.Fatalf("%s: expected a wrong input to fail verification", tc.name)
+		}
+		if err := VerifyFromCalldata(vkFn, proofWords, commitments, pokWords, append(input, big.NewInt(1))); err == nil {
+			t.Fatalf("%s: expected an extra input to be rejected", tc.name)
+		}
+		outOfRange := proofWords
+		outOfRange[0] = fp.Modulus()
+		if err := VerifyFromCalldata(vkFn, outOfRange, commitments, pokWords, input); !errors.Is(err, ErrWordOutOfRange) {
+			t.Fatalf("%s: expected an out of range word to be rejected, got %v", tc.name, err)
+		}
+	}
+
+	// the contract derives the commitment input with keccak256 and rejects a proof made with gnark's default hash
+	defaultHash, err := groth16.Prove(ccs, pk, fullWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accepts, _ := verifyCalldataLikeContract(t, committedVk, defaultHash, committedWitness); accepts {
+		t.Fatal("expected the contract to reject a default hash-to-field proof")
+	}
+	proofWords, commitments, pok, err := proofToSolidityWords(defaultHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input, err := witnessToBigInts(committedWitness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyFromCalldata(filepath.Join(dir, "committed.vk"), proofWords, commitments, pok[:], input); err == nil {
+		t.Fatal("expected a default hash-to-field proof to be rejected like the contract does")
+	}
+}
@@ -0,0 +1,140 @@
+package utilities
+
+import (
+	"container/list"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+// VkCache keeps the verifying keys loaded by ReadVk in memory, evicting the least recently used ones once
+// it holds more than maxEntries keys or more than maxBytes bytes of key files. It is meant for verifier
+// services loading the same keys for every request. A VkCache is safe for concurrent use.
+type VkCache struct {
+	maxEntries int
+	maxBytes   int64
+	reload     bool
+
+	mu      sync.Mutex
+	order   *list.List // of *vkCacheEntry, most recently used first
+	entries map[string]*list.Element
+	size    int64
+}
+
+type vkCacheEntry struct {
+	fn      string
+	vk      groth16.VerifyingKey
+	size    int64
+	modTime time.Time
+}
+
+// VkCacheOption configures a VkCache.
+type VkCacheOption func(*VkCache)
+
+// WithReloadOnChange makes VkCache.Get stat the key file on every call and reload it when its modification
+// time or size changed, at the cost of a stat per call.
+func WithReloadOnChange() VkCacheOption {
+	return func(c *VkCache) {
+		c.reload = true
+	}
+}
+
+// NewVkCache returns an empty VkCache bounded by maxEntries keys and maxBytes bytes of key files, a bound
+// <= 0 being disabled. A key larger than maxBytes on its own is returned by Get but not cached.
+func NewVkCache(maxEntries int, maxBytes int64, opts ...VkCacheOption) *VkCache {
+	c := &VkCache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Get returns the verifying key in fn, reading it with ReadVk unless it is cached. The returned key is
+// shared with other callers and must not be modified. Concurrent misses on the same file may read it
+// more than once, the first key read is kept.
+func (c *VkCache) Get(fn string) (groth16.VerifyingKey, error) {
+	fn = filepath.Clean(fn)
+
+	var (
+		size    int64
+		modTime time.Time
+		statted bool
+	)
+	stat := func() error {
+		info, err := DefaultFilesystem.Stat(fn)
+		if err != nil {
+			return fmt.Errorf("failed to stat verifying key file %s: %w", fn, err)
+		}
+		size, modTime, statted = info.Size(), info.ModTime(), true
+		return nil
+	}
+	if c.reload {
+		if err := stat(); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	if el, ok := c.entries[fn]; ok {
+		e := el.Value.(*vkCacheEntry)
+		if !c.reload || e.size == size && e.modTime.Equal(modTime) {
+			c.order.MoveToFront(el)
+			c.mu.Unlock()
+			return e.vk, nil
+		}
+		c.remove(el)
+	}
+	c.mu.Unlock()
+
+	if !statted {
+		if err := stat(); err != nil {
+			return nil, err
+		}
+	}
+	vk, err := ReadVk(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[fn]; ok {
+		e := el.Value.(*vkCacheEntry)
+		if e.size == size && e.modTime.Equal(modTime) {
+			c.order.MoveToFront(el)
+			return e.vk, nil
+		}
+		c.remove(el)
+	}
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return vk, nil
+	}
+	c.entries[fn] = c.order.PushFront(&vkCacheEntry{fn: fn, vk: vk, size: size, modTime: modTime})
+	c.size += size
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries || c.maxBytes > 0 && c.size > c.maxBytes {
+		c.remove(c.order.Back())
+	}
+	return vk, nil
+}
+
+// Len returns the number of cached keys.
+func (c *VkCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// remove drops el from the cache, c.mu must be held.
+func (c *VkCache) remove(el *list.Element) {
+	e := c.order.Remove(el).(*vkCacheEntry)
+	delete(c.entries, e.fn)
+	c.size -= e.size
+}
@@ -0,0 +1,153 @@
+package utilities
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+func writeTestVks(t testing.TB, dir string, names ...string) (map[string]string, map[string]groth16.VerifyingKey) {
+	fns := make(map[string]string)
+	vks := make(map[string]groth16.VerifyingKey)
+	for _, name := range names {
+		_, vk, _ := genTestProof(t)
+		fns[name] = filepath.Join(dir, name+".vk")
+		vks[name] = vk
+		if err := WriteVk(vk, fns[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return fns, vks
+}
+
+func Test_VkCache(t *testing.T) {
+	fns, vks := writeTestVks(t, t.TempDir(), "a", "b", "c")
+	c := NewVkCache(2, 0)
+
+	get := func(name string) groth16.VerifyingKey {
+		t.Helper()
+		vk, err := c.Get(fns[name])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, diff := VkEqual(vk, vks[name]); !ok {
+			t.Fatalf("%s: unexpected key: %s", name, diff)
+		}
+		return vk
+	}
+
+	a := get("a")
+	b := get("b")
+	if get("a") != a {
+		t.Fatal("expected a cached key to be returned as is")
+	}
+	get("c")
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 cached keys, got %d", c.Len())
+	}
+	if get("a") != a {
+		t.Fatal("expected the recently used key to survive the eviction")
+	}
+	if get("b") == b {
+		t.Fatal("expected the least recently used key to be evicted and read again")
+	}
+
+	if _, err := c.Get(filepath.Join(t.TempDir(), "missing.vk")); err == nil {
+		t.Fatal("expected a missing file to fail")
+	}
+}
+
+func Test_VkCache_MaxBytes(t *testing.T) {
+	fns, _ := writeTestVks(t, t.TempDir(), "a", "b")
+	info, err := os.Stat(fns["a"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewVkCache(0, info.Size())
+	for _, name := range []string{"a", "b"} {
+		if _, err := c.Get(fns[name]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.Len() != 1 {
+		t.Fatalf("expected the byte bound to keep one key, got %d", c.Len())
+	}
+
+	tiny := NewVkCache(0, 1)
+	if _, err := tiny.Get(fns["a"]); err != nil {
+		t.Fatal(err)
+	}
+	if tiny.Len() != 0 {
+		t.Fatal("expected a key larger than the byte bound not to be cached")
+	}
+}
+
+func Test_VkCache_ReloadOnChange(t *testing.T) {
+	fns, vks := writeTestVks(t, t.TempDir(), "a", "b")
+	stale := NewVkCache(0, 0)
+	fresh := NewVkCache(0, 0, WithReloadOnChange())
+	for _, c := range []*VkCache{stale, fresh} {
+		if _, err := c.Get(fns["a"]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := WriteVk(vks["b"], fns["a"]); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(fns["a"], later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	vk, err := stale.Get(fns["a"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VkEqual(vk, vks["a"]); !ok {
+		t.Fatal("expected the cache to keep the key without WithReloadOnChange")
+	}
+	vk, err = fresh.Get(fns["a"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := VkEqual(vk, vks["b"]); !ok {
+		t.Fatal("expected the changed file to be read again")
+	}
+}
+
+func BenchmarkReadVk(b *testing.B) {
+	fns, _ := writeTestVks(b, b.TempDir(), "a")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ReadVk(fns["a"]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVkCache(b *testing.B) {
+	fns, _ := writeTestVks(b, b.TempDir(), "a")
+	c := NewVkCache(16, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Get(fns["a"]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkVkCache_ReloadOnChange(b *testing.B) {
+	fns, _ := writeTestVks(b, b.TempDir(), "a")
+	c := NewVkCache(16, 0, WithReloadOnChange())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := c.Get(fns["a"]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
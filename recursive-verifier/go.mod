@@ -10,6 +10,7 @@ require (
 	github.com/reilabs/gnark-skyscraper v0.0.0-20250819020215-db52e4ee2949
 	github.com/reilabs/go-ark-serialize v0.0.0-20241120151746-4148c0ca17e3
 	github.com/urfave/cli/v2 v2.27.7
+	golang.org/x/crypto v0.39.0
 )
 
 require (
@@ -34,7 +35,7 @@ require (
 	github.com/valyala/tcplisten v1.0.0 // indirect
 	github.com/x448/float16 v0.8.4 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
-	golang.org/x/crypto v0.39.0 // indirect
+	golang.org/x/exp v0.0.0-20250606033433-dcc06ee1d476 // indirect
 	golang.org/x/sync v0.15.0 // indirect
 	golang.org/x/sys v0.33.0 // indirect
 )